@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/completion"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	root := flag.String("root", "content", "content directory with tasks/ and projects/")
+	force := flag.Bool("force", false, "overwrite non-empty completions.yaml")
+	dryRun := flag.Bool("dry-run", false, "print generated files instead of writing them")
+	flag.Parse()
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("failed to setup logger: %v", err)
+	}
+	defer logger.Sync()
+
+	g := &generator{
+		log:    logger,
+		gen:    completion.NewGenerator(logger),
+		force:  *force,
+		dryRun: *dryRun,
+	}
+
+	taskDirs, err := filepath.Glob(filepath.Join(*root, "tasks", "*", "*", "template.go"))
+	if err != nil {
+		logger.Fatal("failed to list tasks", zap.Error(err))
+	}
+	for _, templatePath := range taskDirs {
+		dir := filepath.Dir(templatePath)
+		template, err := os.ReadFile(templatePath)
+		if err != nil {
+			logger.Fatal("failed to read template", zap.String("dir", dir), zap.Error(err))
+		}
+		g.write(dir, completion.TaskSource(string(template)))
+	}
+
+	projectDirs, err := filepath.Glob(filepath.Join(*root, "projects", "*", "go.mod.tmpl"))
+	if err != nil {
+		logger.Fatal("failed to list projects", zap.Error(err))
+	}
+	for _, goModPath := range projectDirs {
+		g.project(filepath.Dir(goModPath))
+	}
+
+	logger.Info("completions generated", zap.Int("written", g.written), zap.Int("skipped", g.skipped))
+}
+
+type generator struct {
+	log     *zap.Logger
+	gen     *completion.Generator
+	force   bool
+	dryRun  bool
+	written int
+	skipped int
+}
+
+// project генерирует подсказки для шагов проекта; шаги идут в порядке имён каталогов (01-, 02-, ...)
+func (g *generator) project(projectDir string) {
+	goMod, err := os.ReadFile(filepath.Join(projectDir, "go.mod.tmpl"))
+	if err != nil {
+		g.log.Fatal("failed to read go.mod.tmpl", zap.String("dir", projectDir), zap.Error(err))
+	}
+
+	stepDirs, err := filepath.Glob(filepath.Join(projectDir, "steps", "*", "template.go"))
+	if err != nil {
+		g.log.Fatal("failed to list steps", zap.String("dir", projectDir), zap.Error(err))
+	}
+	sort.Strings(stepDirs)
+
+	steps := make([]completion.Step, len(stepDirs))
+	for i, templatePath := range stepDirs {
+		dir := filepath.Dir(templatePath)
+		template, err := os.ReadFile(templatePath)
+		if err != nil {
+			g.log.Fatal("failed to read template", zap.String("dir", dir), zap.Error(err))
+		}
+		refs, err := readTree(filepath.Join(dir, "reference"))
+		if err != nil {
+			g.log.Fatal("failed to read reference", zap.String("dir", dir), zap.Error(err))
+		}
+		steps[i] = completion.Step{Template: string(template), Reference: refs}
+	}
+
+	sources := completion.ProjectSources(completion.ModulePath(string(goMod)), steps)
+	for i, templatePath := range stepDirs {
+		g.write(filepath.Dir(templatePath), sources[i])
+	}
+}
+
+func (g *generator) write(dir string, src completion.Source) {
+	path := filepath.Join(dir, "completions.yaml")
+	if !g.force && !isEmpty(path) {
+		g.skipped++
+		return
+	}
+
+	completions, err := g.gen.Generate(src)
+	if err != nil {
+		g.log.Fatal("failed to generate completions", zap.String("dir", dir), zap.Error(err))
+	}
+	if completions == nil {
+		completions = []model.Completion{}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string][]model.Completion{"completions": completions}); err != nil {
+		g.log.Fatal("failed to encode completions", zap.String("dir", dir), zap.Error(err))
+	}
+
+	if g.dryRun {
+		os.Stdout.WriteString("# " + path + "\n")
+		os.Stdout.Write(buf.Bytes())
+	} else if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		g.log.Fatal("failed to write completions", zap.String("path", path), zap.Error(err))
+	}
+	g.written++
+}
+
+// isEmpty — файла нет или в нём нет ни одного пакета
+func isEmpty(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return true
+	}
+	var wrapper struct {
+		Completions []model.Completion `yaml:"completions"`
+	}
+	if err := yaml.Unmarshal(data, &wrapper); err != nil {
+		return false
+	}
+	return len(wrapper.Completions) == 0
+}
+
+// readTree читает все файлы каталога; ключи — пути относительно dir через "/"
+func readTree(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(content)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return files, nil
+	}
+	return files, err
+}
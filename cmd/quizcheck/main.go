@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/GlebMoskalev/go-path-backend/content"
+	"github.com/GlebMoskalev/go-path-backend/internal/config"
+	"github.com/GlebMoskalev/go-path-backend/internal/service"
+	"go.uber.org/zap"
+)
+
+// quizcheck запускает код вопросов с code: в песочнице и сверяет вывод с answer.
+// Ненулевой код выхода означает расхождение — удобно для CI перед выкладкой контента.
+func main() {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("failed to setup logger: %v", err)
+	}
+	defer logger.Sync()
+
+	sandboxService, err := service.NewSandboxService(logger, cfg.Sandbox)
+	if err != nil {
+		logger.Fatal("failed to create sandbox service", zap.Error(err))
+	}
+	quizService, err := service.NewQuizService(content.TheoryFS, "theory", logger, sandboxService, nil, nil, nil, nil, cfg.Quiz)
+	if err != nil {
+		logger.Fatal("failed to load quiz", zap.Error(err))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := quizService.VerifyCode(ctx); err != nil {
+		logger.Error("quiz code answers do not match output", zap.Error(err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/GlebMoskalev/go-path-backend/content"
+	"github.com/GlebMoskalev/go-path-backend/internal/config"
+	"github.com/GlebMoskalev/go-path-backend/internal/database"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/GlebMoskalev/go-path-backend/internal/service"
+	"go.uber.org/zap"
+)
+
+func main() {
+	chapter := flag.String("chapter", "", "task chapter slug, e.g. 08-errors")
+	task := flag.String("task", "", "task slug, e.g. 03-wrap-errors")
+	project := flag.String("project", "", "project slug, e.g. 01-task-manager-api")
+	step := flag.String("step", "", "project step slug, e.g. 06-middleware")
+	all := flag.Bool("all", false, "rejudge every submission instead of the latest per user")
+	dryRun := flag.Bool("dry-run", false, "report changes without saving them")
+	reason := flag.String("reason", "", "why the submissions are rejudged")
+	flag.Parse()
+
+	req := model.RejudgeRequest{
+		All:    *all,
+		DryRun: *dryRun,
+		Reason: *reason,
+	}
+	switch {
+	case *chapter != "" && *task != "" && *project == "" && *step == "":
+		req.Kind = model.RejudgeKindTask
+		req.ChapterSlug = *chapter
+		req.TaskSlug = *task
+	case *project != "" && *step != "" && *chapter == "" && *task == "":
+		req.Kind = model.RejudgeKindProject
+		req.ChapterSlug = *project
+		req.TaskSlug = *step
+	default:
+		flag.Usage()
+		log.Fatal("either -chapter and -task or -project and -step are required")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("failed to setup logger: %v", err)
+	}
+	defer logger.Sync()
+
+	pool, err := database.NewPostgresPool(cfg.Database)
+	if err != nil {
+		logger.Fatal("postgres pool error", zap.Error(err))
+	}
+	defer pool.Close()
+
+	submissionRepo := repository.NewSubmissionRepository(pool)
+	rejudgeRepo := repository.NewRejudgeRepository(pool)
+	theoryProgressRepo := repository.NewTheoryProgressRepository(pool)
+	prerequisiteService := service.NewPrerequisiteService(logger, theoryProgressRepo, submissionRepo, cfg.Prerequisites)
+
+	theoryService, err := service.NewTheoryService(content.TheoryFS, "theory", logger, theoryProgressRepo, prerequisiteService)
+	if err != nil {
+		logger.Fatal("failed to load theory", zap.Error(err))
+	}
+	taskService, err := service.NewTaskService(content.TasksFS, "tasks", logger, submissionRepo, prerequisiteService, theoryService, nil)
+	if err != nil {
+		logger.Fatal("failed to load tasks", zap.Error(err))
+	}
+	projectService, err := service.NewProjectService(content.ProjectsFS, "projects", logger, submissionRepo, prerequisiteService, theoryService, nil)
+	if err != nil {
+		logger.Fatal("failed to create project service", zap.Error(err))
+	}
+	sandboxService, err := service.NewSandboxService(logger, cfg.Sandbox)
+	if err != nil {
+		logger.Fatal("failed to create sandbox service", zap.Error(err))
+	}
+
+	rejudgeService := service.NewRejudgeService(
+		logger,
+		taskService,
+		projectService,
+		sandboxService,
+		submissionRepo,
+		rejudgeRepo,
+		cfg.Rejudge,
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := rejudgeService.Rejudge(ctx, req)
+	if err != nil {
+		logger.Fatal("rejudge failed", zap.Error(err))
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		logger.Fatal("failed to write report", zap.Error(err))
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"log"
 	"net"
 	"net/http"
@@ -10,10 +11,13 @@ import (
 	"syscall"
 
 	"github.com/GlebMoskalev/go-path-backend/content"
+	"github.com/GlebMoskalev/go-path-backend/internal/completion"
 	"github.com/GlebMoskalev/go-path-backend/internal/config"
 	"github.com/GlebMoskalev/go-path-backend/internal/database"
 	"github.com/GlebMoskalev/go-path-backend/internal/handler"
 	"github.com/GlebMoskalev/go-path-backend/internal/middleware"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/oauth"
 	"github.com/GlebMoskalev/go-path-backend/internal/repository"
 	"github.com/GlebMoskalev/go-path-backend/internal/service"
 	"github.com/go-chi/chi/v5"
@@ -43,25 +47,63 @@ func main() {
 	}
 	defer redisClient.Close()
 
-	userRepo := repository.NewUserRepository(pool)
+	authStateCache := repository.NewAuthStateCache(redisClient)
+	userRepo := repository.NewCachedUserRepository(repository.NewUserRepository(pool), authStateCache, cfg.JWT.AuthCacheTTL)
+	identityRepo := repository.NewIdentityRepository(pool)
 	stateRepo := repository.NewStateRepository(redisClient)
+	authCodeRepo := repository.NewAuthCodeRepository(redisClient)
+	linkCodeRepo := repository.NewLinkCodeRepository(redisClient)
+	revokedSessionRepo := repository.NewRevokedSessionRepository(redisClient)
+	sessionRepo := repository.NewSessionRepository(pool)
+	personalTokenRepo := repository.NewPersonalTokenRepository(pool)
 	submissionRepo := repository.NewSubmissionRepository(pool)
 	theoryProgressRepo := repository.NewTheoryProgressRepository(pool)
+	rejudgeRepo := repository.NewRejudgeRepository(pool)
+	quizAttemptRepo := repository.NewQuizAttemptRepository(pool)
+	quizReviewRepo := repository.NewQuizReviewRepository(pool)
+	quizExamRepo := repository.NewQuizExamRepository(redisClient)
+	exportRepo := repository.NewExportRepository(redisClient)
 
+	jwtKeys, err := service.LoadJWTKeys(cfg.JWT)
+	if err != nil {
+		logger.Fatal("failed to load jwt keys", zap.Error(err))
+	}
+
+	providers := []oauth.Provider{oauth.NewGoogle(cfg.Google)}
+	if cfg.GitHub.ClientID != "" {
+		providers = append(providers, oauth.NewGitHub(cfg.GitHub))
+	}
 	authService := service.NewAuthService(
 		logger,
 		userRepo,
+		identityRepo,
 		stateRepo,
-		cfg.Google,
+		authCodeRepo,
+		linkCodeRepo,
+		sessionRepo,
+		revokedSessionRepo,
+		providers,
+		cfg.Google.FrontedCallbackURL,
+		jwtKeys,
 		cfg.JWT,
+		cfg.Admin.Emails,
 	)
-	userService := service.NewUserService(logger, userRepo)
-	theoryService, err := service.NewTheoryService(content.TheoryFS, "theory", logger, theoryProgressRepo)
+	userService := service.NewUserService(logger, userRepo, exportRepo, cfg.Account)
+	personalTokenService := service.NewPersonalTokenService(logger, personalTokenRepo)
+	if err := userService.BootstrapAdmins(context.Background(), cfg.Admin.Emails); err != nil {
+		logger.Fatal("failed to bootstrap admins", zap.Error(err))
+	}
+	prerequisiteService := service.NewPrerequisiteService(logger, theoryProgressRepo, submissionRepo, cfg.Prerequisites)
+	var completionGen *completion.Generator
+	if cfg.Completions.Autogen {
+		completionGen = completion.NewGenerator(logger)
+	}
+	theoryService, err := service.NewTheoryService(content.TheoryFS, "theory", logger, theoryProgressRepo, prerequisiteService)
 	if err != nil {
 		logger.Fatal("failed to load theory", zap.Error(err))
 	}
 
-	taskService, err := service.NewTaskService(content.TasksFS, "tasks", logger, submissionRepo)
+	taskService, err := service.NewTaskService(content.TasksFS, "tasks", logger, submissionRepo, prerequisiteService, theoryService, completionGen)
 	if err != nil {
 		logger.Fatal("failed to load tasks", zap.Error(err))
 	}
@@ -70,71 +112,158 @@ func main() {
 	if err != nil {
 		logger.Fatal("failed to create sandbox service", zap.Error(err))
 	}
-	quizService, err := service.NewQuizService(content.TheoryFS, "theory", logger)
+	quizService, err := service.NewQuizService(content.TheoryFS, "theory", logger, sandboxService, theoryService, quizAttemptRepo, quizReviewRepo, quizExamRepo, cfg.Quiz)
 	if err != nil {
 		logger.Fatal("failed to create quiz service", zap.Error(err))
 	}
+	if cfg.Quiz.RequireLessonQuiz {
+		theoryService.SetLessonQuiz(quizService)
+	}
+	if cfg.Quiz.VerifyCode {
+		if err := quizService.VerifyCode(context.Background()); err != nil {
+			logger.Fatal("quiz code answers do not match output", zap.Error(err))
+		}
+	}
 
-	projectService, err := service.NewProjectService(content.ProjectsFS, "projects", logger, submissionRepo)
+	projectService, err := service.NewProjectService(content.ProjectsFS, "projects", logger, submissionRepo, prerequisiteService, theoryService, completionGen)
 	if err != nil {
 		logger.Fatal("failed to create project service", zap.Error(err))
 	}
-	submissionService := service.NewSubmissionService(logger, taskService, sandboxService, submissionRepo, projectService)
+	if err := prerequisiteService.Validate(); err != nil {
+		logger.Fatal("invalid prerequisites", zap.Error(err))
+	}
+	submissionService := service.NewSubmissionService(logger, taskService, sandboxService, submissionRepo, projectService, prerequisiteService)
+	rejudgeService := service.NewRejudgeService(
+		logger,
+		taskService,
+		projectService,
+		sandboxService,
+		submissionRepo,
+		rejudgeRepo,
+		cfg.Rejudge,
+	)
+	exportService := service.NewExportService(
+		logger,
+		userRepo,
+		identityRepo,
+		sessionRepo,
+		personalTokenRepo,
+		submissionRepo,
+		rejudgeRepo,
+		theoryProgressRepo,
+		quizAttemptRepo,
+		quizReviewRepo,
+		exportRepo,
+		cfg.Export,
+	)
 	aiService := service.NewAIService(logger, taskService, projectService, cfg.AIConfig)
 
-	statsService := service.NewStatsService(theoryService, taskService, projectService)
+	statsService := service.NewStatsService(theoryService, taskService, projectService, quizService)
+	searchService := service.NewSearchService(logger, theoryService, taskService, quizService, projectService)
 	formatService := service.NewFormatService(logger, projectService)
+	renderService := service.NewRenderService(logger)
+	snippetService := service.NewSnippetService(logger, theoryService, sandboxService)
 
 	userHandler := handler.NewUserHandler(userService)
 	authHandler := handler.NewAuthHandler(authService)
-	theoryHandler := handler.NewTheoryHandler(theoryService)
-	taskHandler := handler.NewTaskHandler(taskService, submissionService)
+	personalTokenHandler := handler.NewPersonalTokenHandler(personalTokenService)
+	exportHandler := handler.NewExportHandler(exportService)
+	theoryHandler := handler.NewTheoryHandler(theoryService, renderService, snippetService)
+	taskHandler := handler.NewTaskHandler(taskService, submissionService, renderService)
 	quizHandler := handler.NewQuizHandler(quizService)
-	projectHandler := handler.NewProjectHandler(projectService, submissionService)
+	projectHandler := handler.NewProjectHandler(projectService, submissionService, renderService)
 	aiHandler := handler.NewAIHandler(aiService)
 	statsHandler := handler.NewStatsHandler(statsService)
+	profileHandler := handler.NewProfileHandler(userService, statsService)
 	formatHandler := handler.NewFormatHandler(formatService)
+	rejudgeHandler := handler.NewRejudgeHandler(rejudgeService)
+	searchHandler := handler.NewSearchHandler(searchService)
 
-	authMiddleware := middleware.NewAuthMiddleware(authService, userService)
+	authMiddleware := middleware.NewAuthMiddleware(authService, userService, personalTokenService)
 
 	router := chi.NewRouter()
 
+	router.Get("/.well-known/jwks.json", authHandler.JWKS)
+
 	router.Route("/api", func(api chi.Router) {
-		api.Get("/google/login", authHandler.GoogleLogin)
-		api.Get("/google/callback", authHandler.GoogleCallback)
+		api.Use(middleware.Locale)
+
+		api.Get("/{provider:google|github}/login", authHandler.Login)
+		api.Get("/{provider:google|github}/callback", authHandler.Callback)
 		api.Post("/refresh", authHandler.RefreshToken)
 
 		api.Route("/auth", func(r chi.Router) {
-			r.Use(authMiddleware.Authenticate)
+			r.Post("/token", authHandler.ExchangeCode)
 
-			r.Post("/logout", authHandler.Logout)
+			r.Group(func(r chi.Router) {
+				r.Use(authMiddleware.Authenticate)
+
+				r.Post("/logout", authHandler.Logout)
+				r.Post("/logout/all", authHandler.LogoutAll)
+
+				r.Get("/sessions", authHandler.ListSessions)
+				r.Delete("/sessions/{sessionID}", authHandler.RevokeSession)
+
+				r.Get("/identities", authHandler.ListIdentities)
+				r.Post("/identities/confirm", authHandler.ConfirmLink)
+				r.Post("/identities/{provider}", authHandler.LinkIdentity)
+				r.Delete("/identities/{provider}", authHandler.UnlinkIdentity)
+			})
 		})
 
+		api.Get("/u/{handle}", profileHandler.GetPublic)
+
 		api.Route("/users", func(r chi.Router) {
-			r.Use(authMiddleware.Authenticate)
+			r.Group(func(r chi.Router) {
+				r.Use(authMiddleware.Authenticate)
+
+				r.Put("/profile", userHandler.UpdateProfile)
+				r.Delete("/account", userHandler.DeleteAccount)
+
+				r.Get("/tokens", personalTokenHandler.List)
+				r.Post("/tokens", personalTokenHandler.Create)
+				r.Delete("/tokens/{tokenID}", personalTokenHandler.Revoke)
+
+				r.Post("/export", exportHandler.Start)
+				r.Get("/export", exportHandler.Status)
+			})
+
+			r.Get("/export/download/{token}", exportHandler.Download)
 
-			r.Get("/profile", userHandler.GetProfile)
-			r.Put("/profile", userHandler.UpdateProfile)
-			r.Get("/stats", statsHandler.GetStats)
-			r.Delete("/account", userHandler.DeleteAccount)
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.TokenScope(model.ScopeProfileRead))
+				r.Use(authMiddleware.Authenticate)
+				r.Get("/profile", userHandler.GetProfile)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.TokenScope(model.ScopeProgressRead))
+				r.Use(authMiddleware.Authenticate)
+				r.Get("/stats", statsHandler.GetStats)
+			})
 		})
 
 		api.Route("/theory", func(r chi.Router) {
 			r.Group(func(r chi.Router) {
+				r.Use(middleware.TokenScope(model.ScopeProgressRead))
 				r.Use(authMiddleware.OptionalAuthenticate)
 				r.Get("/", theoryHandler.ListChapter)
 				r.Get("/{chapterSlug}", theoryHandler.GetChapter)
 				r.Get("/{chapterSlug}/{lessonSlug}", theoryHandler.GetLesson)
+				r.Get("/{chapterSlug}/{lessonSlug}/quiz", quizHandler.LessonQuiz)
 			})
 
 			r.Group(func(r chi.Router) {
 				r.Use(authMiddleware.Authenticate)
 				r.Put("/{chapterSlug}/{lessonSlug}/complete", theoryHandler.MarkLessonCompleted)
+				r.Post("/{chapterSlug}/{lessonSlug}/quiz/attempts", quizHandler.StartLessonAttempt)
+				r.Post("/{chapterSlug}/{lessonSlug}/snippets/{snippetID}/run", theoryHandler.RunSnippet)
 			})
 		})
 
 		api.Route("/tasks", func(r chi.Router) {
 			r.Group(func(r chi.Router) {
+				r.Use(middleware.TokenScope(model.ScopeProgressRead))
 				r.Use(authMiddleware.OptionalAuthenticate)
 
 				r.Get("/", taskHandler.ListChapters)
@@ -143,6 +272,7 @@ func main() {
 			})
 
 			r.Group(func(r chi.Router) {
+				r.Use(middleware.TokenScope(model.ScopeSubmissionsWrite))
 				r.Use(authMiddleware.Authenticate)
 				r.Post("/{chapterSlug}/{taskSlug}/submit", taskHandler.Submit)
 			})
@@ -155,11 +285,25 @@ func main() {
 				r.Use(authMiddleware.Authenticate)
 				r.Get("/", quizHandler.GetQuestions)
 				r.Post("/answer", quizHandler.CheckAnswer)
+				r.Post("/{questionID}/run", quizHandler.RunCode)
+
+				r.Get("/review", quizHandler.ReviewQueue)
+				r.Post("/review/answer", quizHandler.ReviewAnswer)
+
+				r.Post("/exams", quizHandler.StartExam)
+				r.Get("/exams/{token}", quizHandler.GetExam)
+				r.Post("/exams/{token}/answer", quizHandler.AnswerExam)
+				r.Post("/exams/{token}/submit", quizHandler.SubmitExam)
+
+				r.Post("/attempts", quizHandler.StartAttempt)
+				r.Post("/attempts/{attemptID}/answer", quizHandler.AnswerAttempt)
+				r.Post("/attempts/{attemptID}/finish", quizHandler.FinishAttempt)
 			})
 		})
 
 		api.Route("/projects", func(r chi.Router) {
 			r.Group(func(r chi.Router) {
+				r.Use(middleware.TokenScope(model.ScopeProgressRead))
 				r.Use(authMiddleware.OptionalAuthenticate)
 
 				r.Get("/", projectHandler.ListProjects)
@@ -168,11 +312,14 @@ func main() {
 			})
 
 			r.Group(func(r chi.Router) {
+				r.Use(middleware.TokenScope(model.ScopeSubmissionsWrite))
 				r.Use(authMiddleware.Authenticate)
 				r.Post("/{projectSlug}/{stepSlug}/submit", projectHandler.Submit)
 			})
 		})
 
+		api.Get("/search", searchHandler.Search)
+
 		api.Route("/analyze", func(r chi.Router) {
 			r.Use(authMiddleware.Authenticate)
 
@@ -188,6 +335,22 @@ func main() {
 			r.Post("/", formatHandler.FormatCode)
 			r.Post("/{projectSlug}/{stepSlug}", formatHandler.FormatProjectCode)
 		})
+
+		api.Route("/admin", func(r chi.Router) {
+			r.Use(authMiddleware.Authenticate)
+			r.Use(middleware.RequireRole(model.RoleAdmin))
+
+			r.Handle("/metrics", expvar.Handler())
+
+			r.Get("/users", userHandler.ListUsers)
+			r.Put("/users/{userID}/role", userHandler.ChangeRole)
+			r.Post("/users/{userID}/deactivate", userHandler.Deactivate)
+			r.Post("/users/{userID}/activate", userHandler.Activate)
+
+			r.Post("/rejudge/tasks/{chapterSlug}/{taskSlug}", rejudgeHandler.RejudgeTask)
+			r.Post("/rejudge/projects/{projectSlug}/{stepSlug}", rejudgeHandler.RejudgeProjectStep)
+			r.Get("/rejudge/jobs/{jobID}", rejudgeHandler.Job)
+		})
 	})
 
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -199,6 +362,9 @@ func main() {
 
 	server := &http.Server{Addr: addr, Handler: router}
 
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	go userService.RunPurge(purgeCtx, cfg.Account.PurgeInterval)
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
@@ -211,6 +377,7 @@ func main() {
 
 	sig := <-quit
 	logger.Info("shutdown signal received", zap.String("signal", sig.String()))
+	stopPurge()
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
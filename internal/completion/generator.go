@@ -0,0 +1,421 @@
+package completion
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"go.uber.org/zap"
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	KindFunction = "function"
+	KindType     = "type"
+	KindConstant = "constant"
+	KindVariable = "variable"
+)
+
+// Source — исходники, по которым строятся подсказки
+type Source struct {
+	// Files — шаблон и эталонное решение: путь -> содержимое
+	Files map[string]string
+	// Local — пакеты предыдущих шагов проекта: import path -> (файл -> содержимое).
+	// Их экспортированные символы попадают в подсказки целиком.
+	Local map[string]map[string]string
+}
+
+// Generator строит completions.yaml по импортам шаблона и решения.
+// Стандартная библиотека и пакеты модулей загружаются через go/packages,
+// документация извлекается через go/doc. Загруженные пакеты кэшируются.
+type Generator struct {
+	log   *zap.Logger
+	fset  *token.FileSet
+	mu    sync.Mutex
+	cache map[string]*doc.Package
+}
+
+func NewGenerator(log *zap.Logger) *Generator {
+	return &Generator{
+		log:   log,
+		fset:  token.NewFileSet(),
+		cache: make(map[string]*doc.Package),
+	}
+}
+
+// Generate возвращает подсказки для пакетов, импортированных в src.Files, и для всех пакетов src.Local.
+// Из импортированных пакетов берутся только использованные символы; если пакет импортирован,
+// но ещё не используется, в подсказки попадают все его экспортированные символы.
+// Пакеты, которые не удалось загрузить, пропускаются с предупреждением.
+func (g *Generator) Generate(src Source) ([]model.Completion, error) {
+	used := make(map[string]map[string]bool)
+	for name, content := range src.Files {
+		if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), name, content, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", name, err)
+		}
+		collectUsed(f, used)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	docs := make(map[string]*doc.Package)
+	var remote []string
+	for importPath := range used {
+		if _, ok := src.Local[importPath]; ok {
+			continue
+		}
+		if p, ok := g.cache[importPath]; ok {
+			docs[importPath] = p
+			continue
+		}
+		remote = append(remote, importPath)
+	}
+	if err := g.loadRemote(remote, docs); err != nil {
+		return nil, err
+	}
+
+	for importPath, files := range src.Local {
+		p, err := g.loadLocal(importPath, files)
+		if err != nil {
+			g.log.Warn("skipping local package", zap.String("package", importPath), zap.Error(err))
+			continue
+		}
+		if p != nil {
+			docs[importPath] = p
+		}
+	}
+
+	completions := make([]model.Completion, 0, len(docs))
+	for importPath, p := range docs {
+		filter := used[importPath]
+		if _, ok := src.Local[importPath]; ok {
+			filter = nil
+		}
+		symbols := g.symbols(p, filter)
+		if len(symbols) == 0 {
+			continue
+		}
+		completions = append(completions, model.Completion{
+			Name:    importPath,
+			Doc:     synopsis(p, p.Doc),
+			Symbols: symbols,
+		})
+	}
+
+	sort.Slice(completions, func(i, j int) bool {
+		return completions[i].Name < completions[j].Name
+	})
+
+	return completions, nil
+}
+
+// loadRemote загружает пакеты одним вызовом go/packages и кладёт их в кэш
+func (g *Generator) loadRemote(importPaths []string, docs map[string]*doc.Package) error {
+	if len(importPaths) == 0 {
+		return nil
+	}
+
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		Fset: g.fset,
+	}
+	pkgs, err := packages.Load(cfg, importPaths...)
+	if err != nil {
+		return fmt.Errorf("load packages: %w", err)
+	}
+
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 || len(pkg.Syntax) == 0 {
+			g.log.Warn("skipping package", zap.String("package", pkg.PkgPath), zap.Any("errors", pkg.Errors))
+			continue
+		}
+		p, err := doc.NewFromFiles(g.fset, pkg.Syntax, pkg.PkgPath)
+		if err != nil {
+			g.log.Warn("skipping package", zap.String("package", pkg.PkgPath), zap.Error(err))
+			continue
+		}
+		g.cache[pkg.PkgPath] = p
+		docs[pkg.PkgPath] = p
+	}
+
+	return nil
+}
+
+// loadLocal разбирает пакет предыдущего шага; тесты не учитываются.
+// Возвращает nil, если в пакете нет файлов кроме тестов.
+func (g *Generator) loadLocal(importPath string, files map[string]string) (*doc.Package, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	sort.Strings(names)
+
+	syntax := make([]*ast.File, 0, len(names))
+	for _, name := range names {
+		f, err := parser.ParseFile(g.fset, name, files[name], parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		syntax = append(syntax, f)
+	}
+
+	return doc.NewFromFiles(g.fset, syntax, importPath)
+}
+
+// symbols собирает экспортированные символы пакета; filter == nil пропускает все
+func (g *Generator) symbols(p *doc.Package, filter map[string]bool) []model.Symbol {
+	if len(filter) == 0 {
+		filter = nil
+	}
+	keep := func(name string) bool {
+		return token.IsExported(name) && (filter == nil || filter[name])
+	}
+
+	var result []model.Symbol
+	addValues := func(values []*doc.Value, kind string) {
+		for _, v := range values {
+			for _, spec := range v.Decl.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, name := range vs.Names {
+					if !keep(name.Name) {
+						continue
+					}
+					result = append(result, model.Symbol{
+						Name:   name.Name,
+						Kind:   kind,
+						Detail: g.valueDetail(v.Decl.Tok, vs, i),
+						Doc:    synopsis(p, specDoc(vs, v.Doc)),
+					})
+				}
+			}
+		}
+	}
+	addFuncs := func(funcs []*doc.Func) {
+		for _, f := range funcs {
+			if !keep(f.Name) {
+				continue
+			}
+			result = append(result, model.Symbol{
+				Name:   f.Name,
+				Kind:   KindFunction,
+				Detail: g.funcDetail(f.Decl),
+				Doc:    synopsis(p, f.Doc),
+			})
+		}
+	}
+
+	addValues(p.Consts, KindConstant)
+	addValues(p.Vars, KindVariable)
+	addFuncs(p.Funcs)
+	for _, t := range p.Types {
+		addValues(t.Consts, KindConstant)
+		addValues(t.Vars, KindVariable)
+		addFuncs(t.Funcs)
+		if !keep(t.Name) {
+			continue
+		}
+		spec := typeSpec(t.Decl, t.Name)
+		if spec == nil {
+			continue
+		}
+		result = append(result, model.Symbol{
+			Name:   t.Name,
+			Kind:   KindType,
+			Detail: g.typeDetail(spec),
+			Doc:    synopsis(p, t.Doc),
+			Fields: g.fields(p, spec, t.Methods),
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// fields — экспортированные поля структуры, методы интерфейса и методы типа
+func (g *Generator) fields(p *doc.Package, spec *ast.TypeSpec, methods []*doc.Func) []model.Field {
+	var result []model.Field
+
+	var list *ast.FieldList
+	switch t := spec.Type.(type) {
+	case *ast.StructType:
+		list = t.Fields
+	case *ast.InterfaceType:
+		list = t.Methods
+	}
+	if list != nil {
+		for _, field := range list.List {
+			for _, name := range field.Names {
+				if !token.IsExported(name.Name) {
+					continue
+				}
+				result = append(result, model.Field{
+					Name: name.Name,
+					Type: g.node(field.Type),
+					Doc:  synopsis(p, fieldDoc(field)),
+				})
+			}
+		}
+	}
+
+	for _, m := range methods {
+		if !token.IsExported(m.Name) {
+			continue
+		}
+		result = append(result, model.Field{
+			Name: m.Name,
+			Type: g.node(m.Decl.Type),
+			Doc:  synopsis(p, m.Doc),
+		})
+	}
+
+	return result
+}
+
+// funcDetail — сигнатура функции без тела, например "func Now() Time"
+func (g *Generator) funcDetail(decl *ast.FuncDecl) string {
+	return g.node(&ast.FuncDecl{Name: decl.Name, Type: decl.Type})
+}
+
+// typeDetail — "type X struct" и "type X interface" без тела, для остальных типов — полное определение
+func (g *Generator) typeDetail(spec *ast.TypeSpec) string {
+	switch spec.Type.(type) {
+	case *ast.StructType:
+		return "type " + spec.Name.Name + " struct"
+	case *ast.InterfaceType:
+		return "type " + spec.Name.Name + " interface"
+	}
+	return "type " + g.node(&ast.TypeSpec{Name: spec.Name, TypeParams: spec.TypeParams, Assign: spec.Assign, Type: spec.Type})
+}
+
+// valueDetail — "const Name = value" или "var Name Type" для i-го имени спецификации
+func (g *Generator) valueDetail(tok token.Token, spec *ast.ValueSpec, i int) string {
+	detail := tok.String() + " " + spec.Names[i].Name
+	if spec.Type != nil {
+		detail += " " + g.node(spec.Type)
+	}
+	if i < len(spec.Values) {
+		detail += " = " + g.node(spec.Values[i])
+	}
+	return detail
+}
+
+func (g *Generator) node(n any) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, g.fset, n); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// collectUsed запоминает символы, к которым файл обращается как pkg.Name
+func collectUsed(f *ast.File, used map[string]map[string]bool) {
+	names := make(map[string]string)
+	for _, imp := range f.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil || importPath == "C" {
+			continue
+		}
+		if used[importPath] == nil {
+			used[importPath] = make(map[string]bool)
+		}
+		name := defaultImportName(importPath)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if name != "_" && name != "." {
+			names[name] = importPath
+		}
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if id, ok := sel.X.(*ast.Ident); ok {
+			if importPath, ok := names[id.Name]; ok {
+				used[importPath][sel.Sel.Name] = true
+			}
+		}
+		return true
+	})
+}
+
+// defaultImportName — имя пакета по последнему элементу пути без суффикса версии (math/rand/v2 -> rand)
+func defaultImportName(importPath string) string {
+	base := path.Base(importPath)
+	if len(base) > 1 && base[0] == 'v' && strings.Trim(base[1:], "0123456789") == "" {
+		if dir := path.Dir(importPath); dir != "." {
+			base = path.Base(dir)
+		}
+	}
+	return strings.TrimPrefix(base, "go-")
+}
+
+func typeSpec(decl *ast.GenDecl, name string) *ast.TypeSpec {
+	for _, spec := range decl.Specs {
+		if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == name {
+			return ts
+		}
+	}
+	return nil
+}
+
+func specDoc(spec *ast.ValueSpec, groupDoc string) string {
+	if spec.Doc != nil {
+		return spec.Doc.Text()
+	}
+	if spec.Comment != nil {
+		return spec.Comment.Text()
+	}
+	return groupDoc
+}
+
+func fieldDoc(field *ast.Field) string {
+	if field.Doc != nil {
+		return field.Doc.Text()
+	}
+	if field.Comment != nil {
+		return field.Comment.Text()
+	}
+	return ""
+}
+
+// synopsis — первое предложение документации без точки в конце, как в рукописных completions.yaml
+func synopsis(p *doc.Package, text string) string {
+	return strings.TrimSuffix(p.Synopsis(text), ".")
+}
+
+// ModulePath возвращает путь модуля из go.mod
+func ModulePath(goMod string) string {
+	for _, line := range strings.Split(goMod, "\n") {
+		line = strings.TrimSpace(line)
+		if rest, ok := strings.CutPrefix(line, "module"); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
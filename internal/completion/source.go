@@ -0,0 +1,50 @@
+package completion
+
+import "path"
+
+// Step — файлы шага проекта: шаблон и эталонные пакеты из reference/ (путь относительно reference/)
+type Step struct {
+	Template  string
+	Reference map[string]string
+}
+
+// TaskSource — исходники задачи: у задач нет эталонного решения, только шаблон
+func TaskSource(template string) Source {
+	return Source{Files: map[string]string{"template.go": template}}
+}
+
+// ProjectSources возвращает Source для каждого шага в порядке прохождения.
+// В Local шага попадают пакеты reference/ всех предыдущих шагов; если пакет
+// встречается в нескольких шагах, более поздний файл заменяет ранний.
+func ProjectSources(modulePath string, steps []Step) []Source {
+	sources := make([]Source, len(steps))
+	local := make(map[string]map[string]string)
+
+	for i, step := range steps {
+		files := map[string]string{"template.go": step.Template}
+		for name, content := range step.Reference {
+			files["reference/"+name] = content
+		}
+
+		snapshot := make(map[string]map[string]string, len(local))
+		for importPath, pkgFiles := range local {
+			snapshot[importPath] = pkgFiles
+		}
+		sources[i] = Source{Files: files, Local: snapshot}
+
+		for name, content := range step.Reference {
+			importPath := modulePath
+			if dir := path.Dir(name); dir != "." {
+				importPath = modulePath + "/" + dir
+			}
+			merged := make(map[string]string, len(local[importPath])+1)
+			for k, v := range local[importPath] {
+				merged[k] = v
+			}
+			merged[path.Base(name)] = content
+			local[importPath] = merged
+		}
+	}
+
+	return sources
+}
@@ -2,20 +2,29 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServiceConfig     `mapstructure:",squash"`
-	JWT      JWTConfig         `mapstructure:",squash"`
-	Google   GoogleOAuthConfig `mapstructure:",squash"`
-	Database DatabaseConfig    `mapstructure:",squash"`
-	Redis    RedisConfig       `mapstructure:",squash"`
-	Sandbox  SandboxConfig     `mapstructure:",squash"`
-	AIConfig AIConfig          `mapstructure:",squash"`
-	Env      string            `mapstructure:"ENV"`
+	Server        ServiceConfig       `mapstructure:",squash"`
+	JWT           JWTConfig           `mapstructure:",squash"`
+	Google        GoogleOAuthConfig   `mapstructure:",squash"`
+	GitHub        GitHubOAuthConfig   `mapstructure:",squash"`
+	Database      DatabaseConfig      `mapstructure:",squash"`
+	Redis         RedisConfig         `mapstructure:",squash"`
+	Sandbox       SandboxConfig       `mapstructure:",squash"`
+	AIConfig      AIConfig            `mapstructure:",squash"`
+	Admin         AdminConfig         `mapstructure:",squash"`
+	Rejudge       RejudgeConfig       `mapstructure:",squash"`
+	Prerequisites PrerequisitesConfig `mapstructure:",squash"`
+	Completions   CompletionsConfig   `mapstructure:",squash"`
+	Quiz          QuizConfig          `mapstructure:",squash"`
+	Export        ExportConfig        `mapstructure:",squash"`
+	Account       AccountConfig       `mapstructure:",squash"`
+	Env           string              `mapstructure:"ENV"`
 }
 
 type DatabaseConfig struct {
@@ -35,6 +44,16 @@ type GoogleOAuthConfig struct {
 	UserInfoURL        string `mapstructure:"GOOGLE_USER_INFO_URL"`
 }
 
+// GitHubOAuthConfig: без GITHUB_CLIENT_ID вход через GitHub выключен
+type GitHubOAuthConfig struct {
+	ClientID     string `mapstructure:"GITHUB_CLIENT_ID"`
+	ClientSecret string `mapstructure:"GITHUB_CLIENT_SECRET"`
+	CallbackURL  string `mapstructure:"GITHUB_CALLBACK_URL"`
+	AuthURL      string `mapstructure:"GITHUB_AUTH_URL"`
+	TokenURL     string `mapstructure:"GITHUB_TOKEN_URL"`
+	APIURL       string `mapstructure:"GITHUB_API_URL"`
+}
+
 type ServiceConfig struct {
 	Host               string        `mapstructure:"SERVER_HOST"`
 	Port               string        `mapstructure:"SERVER_PORT"`
@@ -48,6 +67,15 @@ type JWTConfig struct {
 	RefreshTTLStr string        `mapstructure:"JWT_REFRESH_TTL"`
 	AccessTTL     time.Duration `mapstructure:"-"`
 	RefreshTTL    time.Duration `mapstructure:"-"`
+	// AuthCacheTTLStr — сколько Redis хранит token version, роль и статус пользователя
+	// для middleware; 0 отключает кеш
+	AuthCacheTTLStr string        `mapstructure:"JWT_AUTH_CACHE_TTL"`
+	AuthCacheTTL    time.Duration `mapstructure:"-"`
+	// SigningKeyFile — PEM с приватным ключом RSA или Ed25519; пусто — HS256 с JWT_SECRET
+	SigningKeyFile string `mapstructure:"JWT_SIGNING_KEY_FILE"`
+	// VerifyKeyFilesStr — через запятую PEM прежних ключей, которые ещё принимаются при проверке
+	VerifyKeyFilesStr string   `mapstructure:"JWT_VERIFY_KEY_FILES"`
+	VerifyKeyFiles    []string `mapstructure:"-"`
 }
 
 type RedisConfig struct {
@@ -81,6 +109,60 @@ type AIConfig struct {
 	UserPromptError     string  `mapstructure:"AI_USER_PROMPT_ERROR"`
 }
 
+type AdminConfig struct {
+	// EmailsStr — через запятую email, которым при входе и старте сервера выдаётся роль admin
+	EmailsStr string   `mapstructure:"ADMIN_EMAILS"`
+	Emails    []string `mapstructure:"-"`
+}
+
+type RejudgeConfig struct {
+	// IntervalStr — пауза между запусками песочницы, чтобы не вытеснять живые посылки
+	IntervalStr string        `mapstructure:"REJUDGE_INTERVAL"`
+	Interval    time.Duration `mapstructure:"-"`
+}
+
+type PrerequisitesConfig struct {
+	// Enforce: true = нельзя сдать задачу или урок с непройденными зависимостями,
+	// false = блокировка только отображается
+	Enforce bool `mapstructure:"PREREQUISITES_ENFORCE"`
+}
+
+type CompletionsConfig struct {
+	// Autogen: true = при загрузке контента пустые completions.yaml заполняются генератором.
+	// Требует установленного Go в окружении сервера.
+	Autogen bool `mapstructure:"COMPLETIONS_AUTOGEN"`
+}
+
+type QuizConfig struct {
+	// VerifyCode: true = при старте код вопросов с code: запускается в песочнице,
+	// и расхождение вывода с answer останавливает сервер
+	VerifyCode bool `mapstructure:"QUIZ_VERIFY_CODE"`
+	// ExamQuestionTime — время на один вопрос экзамена; лимит сессии = число вопросов * время
+	ExamQuestionTimeStr string        `mapstructure:"QUIZ_EXAM_QUESTION_TIME"`
+	ExamQuestionTime    time.Duration `mapstructure:"-"`
+	// ExamSecret подписывает токены экзаменационных сессий; по умолчанию JWT_SECRET
+	ExamSecret string `mapstructure:"QUIZ_EXAM_SECRET"`
+	// RequireLessonQuiz: true = урок с мини-квизом засчитывается только после его прохождения
+	RequireLessonQuiz bool `mapstructure:"QUIZ_REQUIRE_LESSON_QUIZ"`
+	// LessonPassScore — доля вопросов урока, на которые последний ответ верен, для прохождения
+	LessonPassScore float64 `mapstructure:"QUIZ_LESSON_PASS_SCORE"`
+}
+
+type ExportConfig struct {
+	// LinkTTLStr — сколько живёт ссылка на архив с выгрузкой данных пользователя
+	LinkTTLStr string        `mapstructure:"EXPORT_LINK_TTL"`
+	LinkTTL    time.Duration `mapstructure:"-"`
+}
+
+type AccountConfig struct {
+	// DeletionGraceStr — сколько удалённый аккаунт можно восстановить до окончательного удаления
+	DeletionGraceStr string        `mapstructure:"ACCOUNT_DELETION_GRACE"`
+	DeletionGrace    time.Duration `mapstructure:"-"`
+	// PurgeIntervalStr — как часто фоновая задача стирает аккаунты с истёкшим сроком; 0 выключает её
+	PurgeIntervalStr string        `mapstructure:"ACCOUNT_PURGE_INTERVAL"`
+	PurgeInterval    time.Duration `mapstructure:"-"`
+}
+
 func LoadConfig() (*Config, error) {
 	viper.SetConfigFile(".env")
 	viper.SetConfigType("env")
@@ -105,6 +187,27 @@ func LoadConfig() (*Config, error) {
 	}
 	cfg.JWT.RefreshTTL = refreshTTL
 
+	if cfg.JWT.AuthCacheTTLStr == "" {
+		cfg.JWT.AuthCacheTTLStr = "1m"
+	}
+	authCacheTTL, err := time.ParseDuration(cfg.JWT.AuthCacheTTLStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_AUTH_CACHE_TTL: %w", err)
+	}
+	cfg.JWT.AuthCacheTTL = authCacheTTL
+
+	for _, path := range strings.Split(cfg.JWT.VerifyKeyFilesStr, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			cfg.JWT.VerifyKeyFiles = append(cfg.JWT.VerifyKeyFiles, path)
+		}
+	}
+
+	for _, email := range strings.Split(cfg.Admin.EmailsStr, ",") {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			cfg.Admin.Emails = append(cfg.Admin.Emails, email)
+		}
+	}
+
 	shutdownTimeout, err := time.ParseDuration(cfg.Server.ShutdownTimeoutStr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid SERVER_SHUTDOWN_TIMEOUT: %w", err)
@@ -117,5 +220,56 @@ func LoadConfig() (*Config, error) {
 	}
 	cfg.Sandbox.Timeout = sandboxTimeout
 
+	if cfg.Rejudge.IntervalStr == "" {
+		cfg.Rejudge.IntervalStr = "2s"
+	}
+	rejudgeInterval, err := time.ParseDuration(cfg.Rejudge.IntervalStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REJUDGE_INTERVAL: %w", err)
+	}
+	cfg.Rejudge.Interval = rejudgeInterval
+
+	if cfg.Quiz.ExamQuestionTimeStr == "" {
+		cfg.Quiz.ExamQuestionTimeStr = "60s"
+	}
+	examQuestionTime, err := time.ParseDuration(cfg.Quiz.ExamQuestionTimeStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid QUIZ_EXAM_QUESTION_TIME: %w", err)
+	}
+	cfg.Quiz.ExamQuestionTime = examQuestionTime
+	if cfg.Quiz.ExamSecret == "" {
+		cfg.Quiz.ExamSecret = cfg.JWT.Secret
+	}
+	if cfg.Quiz.LessonPassScore <= 0 || cfg.Quiz.LessonPassScore > 1 {
+		cfg.Quiz.LessonPassScore = 0.8
+	}
+
+	if cfg.Export.LinkTTLStr == "" {
+		cfg.Export.LinkTTLStr = "24h"
+	}
+	exportLinkTTL, err := time.ParseDuration(cfg.Export.LinkTTLStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EXPORT_LINK_TTL: %w", err)
+	}
+	cfg.Export.LinkTTL = exportLinkTTL
+
+	if cfg.Account.DeletionGraceStr == "" {
+		cfg.Account.DeletionGraceStr = "336h"
+	}
+	deletionGrace, err := time.ParseDuration(cfg.Account.DeletionGraceStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ACCOUNT_DELETION_GRACE: %w", err)
+	}
+	cfg.Account.DeletionGrace = deletionGrace
+
+	if cfg.Account.PurgeIntervalStr == "" {
+		cfg.Account.PurgeIntervalStr = "1h"
+	}
+	purgeInterval, err := time.ParseDuration(cfg.Account.PurgeIntervalStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ACCOUNT_PURGE_INTERVAL: %w", err)
+	}
+	cfg.Account.PurgeInterval = purgeInterval
+
 	return &cfg, nil
 }
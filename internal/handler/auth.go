@@ -2,11 +2,17 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
+	"strings"
 
 	"github.com/GlebMoskalev/go-path-backend/internal/middleware"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
 	"github.com/GlebMoskalev/go-path-backend/internal/service"
 	"github.com/GlebMoskalev/go-path-backend/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
@@ -17,9 +23,20 @@ func NewAuthHandler(authService *service.AuthService) *AuthHandler {
 	return &AuthHandler{authService: authService}
 }
 
-func (h *AuthHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
-	url, err := h.authService.GetGoogleLoginURL(r.Context())
+// Login перенаправляет на провайдера; code_challenge с code_challenge_method=S256 включает PKCE для обмена кода
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	url, err := h.authService.GetLoginURL(r.Context(), chi.URLParam(r, "provider"),
+		query.Get("code_challenge"), query.Get("code_challenge_method"))
 	if err != nil {
+		if errors.Is(err, service.ErrUnknownProvider) {
+			utils.ResponseWithError(w, http.StatusNotFound, "unknown provider")
+			return
+		}
+		if errors.Is(err, service.ErrUnsupportedChallenge) {
+			utils.ResponseWithError(w, http.StatusBadRequest, "only S256 code_challenge_method is supported")
+			return
+		}
 		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to generate login url")
 		return
 	}
@@ -27,7 +44,7 @@ func (h *AuthHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
 }
 
-func (h *AuthHandler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
+func (h *AuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	state := r.URL.Query().Get("state")
 	code := r.URL.Query().Get("code")
 	if code == "" || state == "" {
@@ -35,15 +52,170 @@ func (h *AuthHandler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	redirectURL, err := h.authService.HandleGoogleCallback(r.Context(), code, state)
+	redirectURL, err := h.authService.HandleCallback(r.Context(), chi.URLParam(r, "provider"), code, state)
 	if err != nil {
-		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to authenticate")
+		switch {
+		case errors.Is(err, service.ErrUnknownProvider):
+			utils.ResponseWithError(w, http.StatusNotFound, "unknown provider")
+		case errors.Is(err, service.ErrInvalidState):
+			utils.ResponseWithError(w, http.StatusBadRequest, "invalid or expired state")
+		case errors.Is(err, service.ErrEmailNotVerified):
+			utils.ResponseWithError(w, http.StatusForbidden, "provider email is missing or not verified")
+		case errors.Is(err, service.ErrEmailInUse):
+			utils.ResponseWithError(w, http.StatusConflict, "account with this email exists, sign in and link the provider")
+		case errors.Is(err, service.ErrIdentityInUse):
+			utils.ResponseWithError(w, http.StatusConflict, "provider account is linked to another user")
+		case errors.Is(err, service.ErrProviderLinked):
+			utils.ResponseWithError(w, http.StatusConflict, "provider already linked")
+		case errors.Is(err, service.ErrAccountDisabled):
+			utils.ResponseWithError(w, http.StatusForbidden, "account is deactivated")
+		default:
+			utils.ResponseWithError(w, http.StatusInternalServerError, "failed to authenticate")
+		}
 		return
 	}
 
 	http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
 }
 
+func (h *AuthHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	identities, err := h.authService.ListIdentities(r.Context(), userID)
+	if err != nil {
+		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, identities)
+}
+
+// LinkIdentity возвращает ссылку на провайдера; после согласия callback вернёт на фронтенд
+// link_code, который текущий пользователь подтверждает через ConfirmLink
+func (h *AuthHandler) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	url, err := h.authService.GetLinkURL(r.Context(), userID, chi.URLParam(r, "provider"))
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownProvider) {
+			utils.ResponseWithError(w, http.StatusNotFound, "unknown provider")
+			return
+		}
+		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to generate link url")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, map[string]string{"url": url})
+}
+
+// ConfirmLink завершает привязку провайдера кодом link_code из редиректа
+func (h *AuthHandler) ConfirmLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Code == "" {
+		utils.ResponseWithError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+
+	provider, err := h.authService.ConfirmLink(r.Context(), userID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidLinkCode):
+			utils.ResponseWithError(w, http.StatusBadRequest, "invalid or expired link code")
+		case errors.Is(err, service.ErrIdentityInUse):
+			utils.ResponseWithError(w, http.StatusConflict, "provider account is linked to another user")
+		case errors.Is(err, service.ErrProviderLinked):
+			utils.ResponseWithError(w, http.StatusConflict, "provider already linked")
+		default:
+			utils.ResponseWithError(w, http.StatusInternalServerError, "failed to link identity")
+		}
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, map[string]string{"message": "identity linked", "provider": provider})
+}
+
+func (h *AuthHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	err := h.authService.UnlinkIdentity(r.Context(), userID, chi.URLParam(r, "provider"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrIdentityNotFound):
+			utils.ResponseWithError(w, http.StatusNotFound, "identity not found")
+		case errors.Is(err, service.ErrLastIdentity):
+			utils.ResponseWithError(w, http.StatusConflict, "cannot unlink the only identity")
+		default:
+			utils.ResponseWithError(w, http.StatusInternalServerError, "failed to unlink identity")
+		}
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, map[string]string{"message": "identity unlinked"})
+}
+
+// ExchangeCode выдаёт токены по одноразовому коду из редиректа. Если в редиректе
+// пришёл delete_after, аккаунт ожидает удаления и обмен нужен с restore=true.
+func (h *AuthHandler) ExchangeCode(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code         string `json:"code"`
+		CodeVerifier string `json:"code_verifier"`
+		Restore      bool   `json:"restore"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Code == "" {
+		utils.ResponseWithError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+
+	tokenPair, err := h.authService.ExchangeCode(r.Context(), req.Code, req.CodeVerifier, req.Restore, clientInfo(r))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAuthCode) {
+			utils.ResponseWithError(w, http.StatusUnauthorized, "invalid or expired code")
+			return
+		}
+		if errors.Is(err, service.ErrAccountDisabled) {
+			utils.ResponseWithError(w, http.StatusForbidden, "account is deactivated")
+			return
+		}
+		if errors.Is(err, service.ErrAccountPendingDeletion) {
+			utils.ResponseWithError(w, http.StatusConflict, "account is scheduled for deletion, sign in again and exchange the code with restore=true")
+			return
+		}
+		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to exchange code")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, tokenPair)
+}
+
 func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		RefreshToken string `json:"refresh_token"`
@@ -54,8 +226,16 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokenPair, err := h.authService.RefreshToken(r.Context(), req.RefreshToken)
+	tokenPair, err := h.authService.RefreshToken(r.Context(), req.RefreshToken, clientInfo(r))
 	if err != nil {
+		if errors.Is(err, service.ErrRefreshReused) {
+			utils.ResponseWithError(w, http.StatusUnauthorized, "refresh token reuse detected, session revoked")
+			return
+		}
+		if errors.Is(err, service.ErrAccountDisabled) {
+			utils.ResponseWithError(w, http.StatusForbidden, "account is deactivated")
+			return
+		}
 		utils.ResponseWithError(w, http.StatusUnauthorized, "invalid or expired refresh token")
 		return
 	}
@@ -70,10 +250,90 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.authService.Logout(r.Context(), userID); err != nil {
+	if err := h.authService.Logout(r.Context(), userID, middleware.SessionIDFromContext(r.Context())); err != nil {
 		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to logout")
 		return
 	}
 
 	utils.ResponseWithJSON(w, http.StatusOK, map[string]string{"message": "logged out successfully"})
 }
+
+// LogoutAll завершает сессии на всех устройствах
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := h.authService.LogoutAll(r.Context(), userID); err != nil {
+		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to logout")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, map[string]string{"message": "logged out on all devices"})
+}
+
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(r.Context(), userID, middleware.SessionIDFromContext(r.Context()))
+	if err != nil {
+		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, sessions)
+}
+
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "sessionID"))
+	if err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid session id")
+		return
+	}
+
+	if err := h.authService.RevokeSession(r.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			utils.ResponseWithError(w, http.StatusNotFound, "session not found")
+			return
+		}
+		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to revoke session")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, map[string]string{"message": "session revoked"})
+}
+
+// JWKS публикует ключи проверки токенов; кеш короче перекрытия ключей при ротации
+func (h *AuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	utils.ResponseWithJSON(w, http.StatusOK, h.authService.JWKS())
+}
+
+// clientInfo — адрес и User-Agent клиента для списка сессий. X-Real-IP и X-Forwarded-For
+// выставляет прокси перед сервером; значение справочное и не используется для проверок.
+func clientInfo(r *http.Request) model.ClientInfo {
+	ip := r.Header.Get("X-Real-IP")
+	if ip == "" {
+		ip, _, _ = strings.Cut(r.Header.Get("X-Forwarded-For"), ",")
+		ip = strings.TrimSpace(ip)
+	}
+	if ip == "" {
+		ip = r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+	}
+	return model.ClientInfo{IP: ip, UserAgent: r.UserAgent()}
+}
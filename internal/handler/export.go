@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/middleware"
+	"github.com/GlebMoskalev/go-path-backend/internal/service"
+	"github.com/GlebMoskalev/go-path-backend/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type ExportHandler struct {
+	exportService *service.ExportService
+}
+
+func NewExportHandler(exportService *service.ExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// Start запускает сборку архива; готовность и ссылку показывает Status
+func (h *ExportHandler) Start(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	job, err := h.exportService.Start(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrExportInProgress) {
+			utils.ResponseWithError(w, http.StatusConflict, "export already in progress")
+			return
+		}
+		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to start export")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusAccepted, job)
+}
+
+func (h *ExportHandler) Status(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	job, err := h.exportService.Status(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrExportNotFound) {
+			utils.ResponseWithError(w, http.StatusNotFound, "export not found")
+			return
+		}
+		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, job)
+}
+
+// Download отдаёт архив без авторизации: доступ даёт только знание токена из ссылки
+func (h *ExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	data, err := h.exportService.Download(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		if errors.Is(err, service.ErrExportNotFound) {
+			utils.ResponseWithError(w, http.StatusNotFound, "export not found or link expired")
+			return
+		}
+		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="go-path-export-%s.zip"`, time.Now().Format("2006-01-02")))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/middleware"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/service"
+	"github.com/GlebMoskalev/go-path-backend/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type PersonalTokenHandler struct {
+	tokenService *service.PersonalTokenService
+}
+
+func NewPersonalTokenHandler(tokenService *service.PersonalTokenService) *PersonalTokenHandler {
+	return &PersonalTokenHandler{tokenService: tokenService}
+}
+
+func (h *PersonalTokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	tokens, err := h.tokenService.List(r.Context(), userID)
+	if err != nil {
+		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, tokens)
+}
+
+// Create выпускает токен; поле token в ответе показывается один раз
+func (h *PersonalTokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		Name      string        `json:"name"`
+		Scopes    []model.Scope `json:"scopes"`
+		ExpiresAt *time.Time    `json:"expires_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	token, err := h.tokenService.Create(r.Context(), userID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTokenName),
+			errors.Is(err, service.ErrInvalidScope),
+			errors.Is(err, service.ErrTokenExpiryPast):
+			utils.ResponseWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrTooManyTokens):
+			utils.ResponseWithError(w, http.StatusConflict, "too many personal tokens, revoke unused ones")
+		default:
+			utils.ResponseWithError(w, http.StatusInternalServerError, "failed to create token")
+		}
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, token)
+}
+
+func (h *PersonalTokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	tokenID, err := uuid.Parse(chi.URLParam(r, "tokenID"))
+	if err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid token id")
+		return
+	}
+
+	if err := h.tokenService.Revoke(r.Context(), userID, tokenID); err != nil {
+		if errors.Is(err, service.ErrPersonalTokenNotFound) {
+			utils.ResponseWithError(w, http.StatusNotFound, "token not found")
+			return
+		}
+		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to revoke token")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, map[string]string{"message": "token revoked"})
+}
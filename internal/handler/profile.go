@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/service"
+	"github.com/GlebMoskalev/go-path-backend/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type ProfileHandler struct {
+	userService  *service.UserService
+	statsService *service.StatsService
+}
+
+func NewProfileHandler(userService *service.UserService, statsService *service.StatsService) *ProfileHandler {
+	return &ProfileHandler{userService: userService, statsService: statsService}
+}
+
+// GetPublic — публичная страница пользователя по handle, доступна без входа
+func (h *ProfileHandler) GetPublic(w http.ResponseWriter, r *http.Request) {
+	user, err := h.userService.GetPublicUser(r.Context(), chi.URLParam(r, "handle"))
+	if err != nil {
+		if errors.Is(err, service.ErrProfileNotFound) {
+			utils.ResponseWithError(w, http.StatusNotFound, "profile not found")
+			return
+		}
+		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to get profile")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, h.statsService.GetPublicProfile(r.Context(), user))
+}
@@ -14,29 +14,34 @@ import (
 type ProjectHandler struct {
 	projectService    *service.ProjectService
 	submissionService *service.SubmissionService
+	renderService     *service.RenderService
 }
 
 func NewProjectHandler(
 	projectService *service.ProjectService,
 	submissionService *service.SubmissionService,
+	renderService *service.RenderService,
 ) *ProjectHandler {
 	return &ProjectHandler{
 		projectService:    projectService,
 		submissionService: submissionService,
+		renderService:     renderService,
 	}
 }
 
 func (h *ProjectHandler) ListProjects(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.OptionalUserID(r.Context())
-	projects := h.projectService.ListProjects(r.Context(), userID)
+	locale := middleware.LocaleFromContext(r.Context())
+	projects := h.projectService.ListProjects(r.Context(), userID, locale)
 	utils.ResponseWithJSON(w, http.StatusOK, projects)
 }
 
 func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "projectSlug")
 	userID := middleware.OptionalUserID(r.Context())
+	locale := middleware.LocaleFromContext(r.Context())
 
-	project, err := h.projectService.GetProject(r.Context(), slug, userID)
+	project, err := h.projectService.GetProject(r.Context(), slug, userID, locale)
 	if err != nil {
 		if errors.Is(err, service.ErrProjectNotFound) {
 			utils.ResponseWithError(w, http.StatusNotFound, "project not found")
@@ -53,8 +58,15 @@ func (h *ProjectHandler) GetStep(w http.ResponseWriter, r *http.Request) {
 	projectSlug := chi.URLParam(r, "projectSlug")
 	stepSlug := chi.URLParam(r, "stepSlug")
 	userID := middleware.OptionalUserID(r.Context())
+	locale := middleware.LocaleFromContext(r.Context())
 
-	step, err := h.projectService.GetStep(r.Context(), projectSlug, stepSlug, userID)
+	format, err := service.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "unsupported format")
+		return
+	}
+
+	step, err := h.projectService.GetStep(r.Context(), projectSlug, stepSlug, userID, locale)
 	if err != nil {
 		if errors.Is(err, service.ErrProjectNotFound) || errors.Is(err, service.ErrProjectStepNotFound) {
 			utils.ResponseWithError(w, http.StatusNotFound, "step not found")
@@ -64,6 +76,13 @@ func (h *ProjectHandler) GetStep(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if format == service.FormatHTML {
+		if err := h.renderService.RenderStep(&step); err != nil {
+			utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+
 	utils.ResponseWithJSON(w, http.StatusOK, step)
 }
 
@@ -101,6 +120,10 @@ func (h *ProjectHandler) Submit(w http.ResponseWriter, r *http.Request) {
 			utils.ResponseWithError(w, http.StatusNotFound, "step not found")
 			return
 		}
+		if errors.Is(err, service.ErrItemLocked) {
+			utils.ResponseWithError(w, http.StatusForbidden, "complete prerequisites first")
+			return
+		}
 		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
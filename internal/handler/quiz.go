@@ -7,8 +7,11 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/GlebMoskalev/go-path-backend/internal/middleware"
 	"github.com/GlebMoskalev/go-path-backend/internal/service"
 	"github.com/GlebMoskalev/go-path-backend/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
 type QuizHandler struct {
@@ -20,7 +23,7 @@ func NewQuizHandler(quizService *service.QuizService) *QuizHandler {
 }
 
 func (h *QuizHandler) ListChapters(w http.ResponseWriter, r *http.Request) {
-	chapters := h.quizService.ListChapters()
+	chapters := h.quizService.ListChapters(middleware.LocaleFromContext(r.Context()))
 	utils.ResponseWithJSON(w, http.StatusOK, chapters)
 }
 
@@ -43,14 +46,26 @@ func (h *QuizHandler) GetQuestions(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	questions := h.quizService.GetQuestions(chapterSlugs, limit)
+	// prefer_new=true ставит вперёд новые вопросы и вопросы с неверным ответом
+	var userID *uuid.UUID
+	if r.URL.Query().Get("prefer_new") == "true" {
+		userID = middleware.OptionalUserID(r.Context())
+	}
+
+	questions := h.quizService.GetQuestions(r.Context(), userID, chapterSlugs, limit, middleware.LocaleFromContext(r.Context()))
 	utils.ResponseWithJSON(w, http.StatusOK, questions)
 }
 
 func (h *QuizHandler) CheckAnswer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
 	var req struct {
-		QuestionID string `json:"question_id"`
-		Answer     string `json:"answer"`
+		QuestionID string     `json:"question_id"`
+		Answer     quizAnswer `json:"answer"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -62,20 +77,421 @@ func (h *QuizHandler) CheckAnswer(w http.ResponseWriter, r *http.Request) {
 		utils.ResponseWithError(w, http.StatusBadRequest, "question_id is required")
 		return
 	}
-	if req.Answer == "" {
+	if len(req.Answer) == 0 {
 		utils.ResponseWithError(w, http.StatusBadRequest, "answer is required")
 		return
 	}
 
-	result, err := h.quizService.CheckAnswer(req.QuestionID, req.Answer)
+	result, err := h.quizService.CheckAnswer(r.Context(), userID, req.QuestionID, req.Answer, middleware.LocaleFromContext(r.Context()))
 	if err != nil {
-		if errors.Is(err, service.ErrQuestionNotFound) {
+		switch {
+		case errors.Is(err, service.ErrQuestionNotFound):
 			utils.ResponseWithError(w, http.StatusNotFound, "question not found")
+		case errors.Is(err, service.ErrQuestionInExam):
+			utils.ResponseWithError(w, http.StatusForbidden, "question is part of an active exam")
+		default:
+			utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, result)
+}
+
+// RunCode показывает настоящий вывод кода вопроса; ответ обязателен, чтобы вывод не подсказывал его
+func (h *QuizHandler) RunCode(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	questionID := chi.URLParam(r, "questionID")
+
+	var req struct {
+		Answer quizAnswer `json:"answer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.Answer) == 0 {
+		utils.ResponseWithError(w, http.StatusBadRequest, "answer is required")
+		return
+	}
+
+	result, err := h.quizService.RunCode(r.Context(), userID, questionID, req.Answer, middleware.LocaleFromContext(r.Context()))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrQuestionNotFound):
+			utils.ResponseWithError(w, http.StatusNotFound, "question not found")
+		case errors.Is(err, service.ErrQuestionNoCode):
+			utils.ResponseWithError(w, http.StatusUnprocessableEntity, "question has no code")
+		case errors.Is(err, service.ErrQuestionInExam):
+			utils.ResponseWithError(w, http.StatusForbidden, "question is part of an active exam")
+		default:
+			utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, result)
+}
+
+// LessonQuiz отдаёт мини-квиз урока; для авторизованного пользователя — и статус прохождения
+func (h *QuizHandler) LessonQuiz(w http.ResponseWriter, r *http.Request) {
+	chapterSlug := chi.URLParam(r, "chapterSlug")
+	lessonSlug := chi.URLParam(r, "lessonSlug")
+	userID := middleware.OptionalUserID(r.Context())
+
+	quiz, err := h.quizService.LessonQuiz(r.Context(), userID, chapterSlug, lessonSlug, middleware.LocaleFromContext(r.Context()))
+	if err != nil {
+		if errors.Is(err, service.ErrChapterNotFound) || errors.Is(err, service.ErrLessonNotFound) {
+			utils.ResponseWithError(w, http.StatusNotFound, "lesson not found")
+			return
+		}
+		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, quiz)
+}
+
+func (h *QuizHandler) StartLessonAttempt(w http.ResponseWriter, r *http.Request) {
+	chapterSlug := chi.URLParam(r, "chapterSlug")
+	lessonSlug := chi.URLParam(r, "lessonSlug")
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	attempt, err := h.quizService.StartLessonAttempt(r.Context(), userID, chapterSlug, lessonSlug, middleware.LocaleFromContext(r.Context()))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrChapterNotFound) || errors.Is(err, service.ErrLessonNotFound):
+			utils.ResponseWithError(w, http.StatusNotFound, "lesson not found")
+		case errors.Is(err, service.ErrNoQuizQuestions):
+			utils.ResponseWithError(w, http.StatusBadRequest, "lesson has no quiz")
+		default:
+			utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, attempt)
+}
+
+func (h *QuizHandler) StartAttempt(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		Chapters  []string `json:"chapters"`
+		Limit     int      `json:"limit"`
+		PreferNew bool     `json:"prefer_new"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Limit < 0 {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	attempt, err := h.quizService.StartAttempt(r.Context(), userID, req.Chapters, req.Limit, req.PreferNew, middleware.LocaleFromContext(r.Context()))
+	if err != nil {
+		if errors.Is(err, service.ErrNoQuizQuestions) {
+			utils.ResponseWithError(w, http.StatusBadRequest, "no questions for selected chapters")
+			return
+		}
+		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, attempt)
+}
+
+func (h *QuizHandler) AnswerAttempt(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	attemptID, err := uuid.Parse(chi.URLParam(r, "attemptID"))
+	if err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid attempt id")
+		return
+	}
+
+	var req struct {
+		QuestionID string     `json:"question_id"`
+		Answer     quizAnswer `json:"answer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.QuestionID == "" {
+		utils.ResponseWithError(w, http.StatusBadRequest, "question_id is required")
+		return
+	}
+	if len(req.Answer) == 0 {
+		utils.ResponseWithError(w, http.StatusBadRequest, "answer is required")
+		return
+	}
+
+	result, err := h.quizService.AnswerAttempt(r.Context(), userID, attemptID, req.QuestionID, req.Answer, middleware.LocaleFromContext(r.Context()))
+	if err != nil {
+		writeAttemptError(w, err)
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, result)
+}
+
+func (h *QuizHandler) FinishAttempt(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	attemptID, err := uuid.Parse(chi.URLParam(r, "attemptID"))
+	if err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid attempt id")
+		return
+	}
+
+	attempt, err := h.quizService.FinishAttempt(r.Context(), userID, attemptID)
+	if err != nil {
+		writeAttemptError(w, err)
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, attempt)
+}
+
+func (h *QuizHandler) ReviewQueue(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	limit := 0
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit < 0 {
+			utils.ResponseWithError(w, http.StatusBadRequest, "invalid limit")
 			return
 		}
+	}
+
+	items, err := h.quizService.ReviewQueue(r.Context(), userID, limit, middleware.LocaleFromContext(r.Context()))
+	if err != nil {
 		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
+	utils.ResponseWithJSON(w, http.StatusOK, items)
+}
+
+func (h *QuizHandler) ReviewAnswer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		QuestionID string     `json:"question_id"`
+		Answer     quizAnswer `json:"answer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.QuestionID == "" {
+		utils.ResponseWithError(w, http.StatusBadRequest, "question_id is required")
+		return
+	}
+	if len(req.Answer) == 0 {
+		utils.ResponseWithError(w, http.StatusBadRequest, "answer is required")
+		return
+	}
+
+	result, err := h.quizService.ReviewAnswer(r.Context(), userID, req.QuestionID, req.Answer, middleware.LocaleFromContext(r.Context()))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrQuestionNotFound):
+			utils.ResponseWithError(w, http.StatusNotFound, "question not found")
+		case errors.Is(err, service.ErrQuestionInExam):
+			utils.ResponseWithError(w, http.StatusForbidden, "question is part of an active exam")
+		default:
+			utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+
 	utils.ResponseWithJSON(w, http.StatusOK, result)
 }
+
+func writeAttemptError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrQuizAttemptNotFound):
+		utils.ResponseWithError(w, http.StatusNotFound, "quiz attempt not found")
+	case errors.Is(err, service.ErrQuestionNotFound):
+		utils.ResponseWithError(w, http.StatusNotFound, "question not found")
+	case errors.Is(err, service.ErrQuizAttemptFinished):
+		utils.ResponseWithError(w, http.StatusConflict, "quiz attempt already finished")
+	case errors.Is(err, service.ErrQuestionAnswered):
+		utils.ResponseWithError(w, http.StatusConflict, "question already answered")
+	case errors.Is(err, service.ErrQuestionInExam):
+		utils.ResponseWithError(w, http.StatusForbidden, "question is part of an active exam")
+	default:
+		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// StartExam начинает экзамен; ответы и правильность скрыты до сдачи
+func (h *QuizHandler) StartExam(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		Chapters []string `json:"chapters"`
+		Limit    int      `json:"limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Limit < 0 {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	exam, err := h.quizService.StartExam(r.Context(), userID, req.Chapters, req.Limit, middleware.LocaleFromContext(r.Context()))
+	if err != nil {
+		if errors.Is(err, service.ErrNoQuizQuestions) {
+			utils.ResponseWithError(w, http.StatusBadRequest, "no questions for selected chapters")
+			return
+		}
+		writeExamError(w, err)
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, exam)
+}
+
+func (h *QuizHandler) GetExam(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	exam, err := h.quizService.GetExam(r.Context(), userID, chi.URLParam(r, "token"), middleware.LocaleFromContext(r.Context()))
+	if err != nil {
+		writeExamError(w, err)
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, exam)
+}
+
+func (h *QuizHandler) AnswerExam(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req struct {
+		QuestionID string     `json:"question_id"`
+		Answer     quizAnswer `json:"answer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.QuestionID == "" {
+		utils.ResponseWithError(w, http.StatusBadRequest, "question_id is required")
+		return
+	}
+	if len(req.Answer) == 0 {
+		utils.ResponseWithError(w, http.StatusBadRequest, "answer is required")
+		return
+	}
+
+	exam, err := h.quizService.AnswerExam(r.Context(), userID, chi.URLParam(r, "token"), req.QuestionID, req.Answer)
+	if err != nil {
+		writeExamError(w, err)
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, exam)
+}
+
+func (h *QuizHandler) SubmitExam(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	exam, err := h.quizService.SubmitExam(r.Context(), userID, chi.URLParam(r, "token"), middleware.LocaleFromContext(r.Context()))
+	if err != nil {
+		writeExamError(w, err)
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, exam)
+}
+
+func writeExamError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrQuizExamNotFound):
+		utils.ResponseWithError(w, http.StatusNotFound, "quiz exam not found")
+	case errors.Is(err, service.ErrQuestionNotFound):
+		utils.ResponseWithError(w, http.StatusNotFound, "question not found")
+	case errors.Is(err, service.ErrQuizExamActive):
+		utils.ResponseWithError(w, http.StatusConflict, "another quiz exam is in progress")
+	case errors.Is(err, service.ErrQuizExamSubmitted):
+		utils.ResponseWithError(w, http.StatusConflict, "quiz exam already submitted")
+	case errors.Is(err, service.ErrQuizExamOver):
+		utils.ResponseWithError(w, http.StatusConflict, "quiz exam time is over")
+	default:
+		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// quizAnswer принимает строку (ID варианта для single, текст для text)
+// или массив ID вариантов (multi) и строк (order)
+type quizAnswer []string
+
+func (a *quizAnswer) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		if one != "" {
+			*a = quizAnswer{one}
+		}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
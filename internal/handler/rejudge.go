@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/service"
+	"github.com/GlebMoskalev/go-path-backend/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type RejudgeHandler struct {
+	rejudgeService *service.RejudgeService
+}
+
+func NewRejudgeHandler(rejudgeService *service.RejudgeService) *RejudgeHandler {
+	return &RejudgeHandler{rejudgeService: rejudgeService}
+}
+
+func (h *RejudgeHandler) RejudgeTask(w http.ResponseWriter, r *http.Request) {
+	h.rejudge(w, r, model.RejudgeKindTask, chi.URLParam(r, "chapterSlug"), chi.URLParam(r, "taskSlug"))
+}
+
+func (h *RejudgeHandler) RejudgeProjectStep(w http.ResponseWriter, r *http.Request) {
+	h.rejudge(w, r, model.RejudgeKindProject, chi.URLParam(r, "projectSlug"), chi.URLParam(r, "stepSlug"))
+}
+
+func (h *RejudgeHandler) rejudge(w http.ResponseWriter, r *http.Request, kind, chapterSlug, taskSlug string) {
+	var req model.RejudgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Kind = kind
+	req.ChapterSlug = chapterSlug
+	req.TaskSlug = taskSlug
+
+	job, err := h.rejudgeService.Start(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrRejudgeReasonEmpty):
+			utils.ResponseWithError(w, http.StatusBadRequest, "reason is required")
+		case errors.Is(err, service.ErrRejudgeInProgress):
+			utils.ResponseWithError(w, http.StatusConflict, "rejudge already in progress")
+		case errors.Is(err, service.ErrTaskNotFound), errors.Is(err, service.ErrTaskChapterNotFound):
+			utils.ResponseWithError(w, http.StatusNotFound, "task not found")
+		case errors.Is(err, service.ErrProjectNotFound), errors.Is(err, service.ErrProjectStepNotFound):
+			utils.ResponseWithError(w, http.StatusNotFound, "step not found")
+		default:
+			utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusAccepted, job)
+}
+
+// Job — состояние фоновой перепроверки и отчёт после её завершения
+func (h *RejudgeHandler) Job(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobID"))
+	if err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid job id")
+		return
+	}
+
+	job, err := h.rejudgeService.Job(jobID)
+	if err != nil {
+		if errors.Is(err, service.ErrRejudgeJobNotFound) {
+			utils.ResponseWithError(w, http.StatusNotFound, "rejudge job not found")
+			return
+		}
+		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, job)
+}
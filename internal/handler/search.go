@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/middleware"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/service"
+	"github.com/GlebMoskalev/go-path-backend/internal/utils"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	maxSearchQueryLen  = 200
+)
+
+type SearchHandler struct {
+	searchService *service.SearchService
+}
+
+func NewSearchHandler(searchService *service.SearchService) *SearchHandler {
+	return &SearchHandler{searchService: searchService}
+}
+
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		utils.ResponseWithError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	if utf8.RuneCountInString(query) > maxSearchQueryLen {
+		utils.ResponseWithError(w, http.StatusBadRequest, "query too long")
+		return
+	}
+
+	var types []string
+	if typesParam := r.URL.Query().Get("types"); typesParam != "" {
+		types = strings.Split(typesParam, ",")
+		for _, t := range types {
+			switch t {
+			case model.SearchTypeLesson, model.SearchTypeTask, model.SearchTypeQuiz, model.SearchTypeStep:
+			default:
+				utils.ResponseWithError(w, http.StatusBadRequest, "invalid type: "+t)
+				return
+			}
+		}
+	}
+
+	limit := defaultSearchLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		var err error
+		limit, err = strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			utils.ResponseWithError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		if limit > maxSearchLimit {
+			limit = maxSearchLimit
+		}
+	}
+
+	result := h.searchService.Search(query, types, middleware.LocaleFromContext(r.Context()), limit)
+	utils.ResponseWithJSON(w, http.StatusOK, result)
+}
@@ -14,28 +14,33 @@ import (
 type TaskHandler struct {
 	taskService       *service.TaskService
 	submissionService *service.SubmissionService
+	renderService     *service.RenderService
 }
 
 func NewTaskHandler(
 	taskService *service.TaskService,
 	submissionService *service.SubmissionService,
+	renderService *service.RenderService,
 ) *TaskHandler {
 	return &TaskHandler{
 		taskService:       taskService,
 		submissionService: submissionService,
+		renderService:     renderService,
 	}
 }
 func (h *TaskHandler) ListChapters(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.OptionalUserID(r.Context())
-	chapters := h.taskService.ListChapters(r.Context(), userID)
+	locale := middleware.LocaleFromContext(r.Context())
+	chapters := h.taskService.ListChapters(r.Context(), userID, locale)
 	utils.ResponseWithJSON(w, http.StatusOK, chapters)
 }
 
 func (h *TaskHandler) GetChapter(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "chapterSlug")
 	userID := middleware.OptionalUserID(r.Context())
+	locale := middleware.LocaleFromContext(r.Context())
 
-	chapter, err := h.taskService.GetChapter(r.Context(), slug, userID)
+	chapter, err := h.taskService.GetChapter(r.Context(), slug, userID, locale)
 	if err != nil {
 		if errors.Is(err, service.ErrTaskChapterNotFound) {
 			utils.ResponseWithError(w, http.StatusNotFound, "chapter not found")
@@ -52,8 +57,15 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 	chapterSlug := chi.URLParam(r, "chapterSlug")
 	taskSlug := chi.URLParam(r, "taskSlug")
 	userID := middleware.OptionalUserID(r.Context())
+	locale := middleware.LocaleFromContext(r.Context())
 
-	task, err := h.taskService.GetTask(r.Context(), chapterSlug, taskSlug, userID)
+	format, err := service.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "unsupported format")
+		return
+	}
+
+	task, err := h.taskService.GetTask(r.Context(), chapterSlug, taskSlug, userID, locale)
 	if err != nil {
 		if errors.Is(err, service.ErrTaskNotFound) || errors.Is(err, service.ErrTaskChapterNotFound) {
 			utils.ResponseWithError(w, http.StatusNotFound, "task not found")
@@ -63,6 +75,13 @@ func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if format == service.FormatHTML {
+		if err := h.renderService.RenderTask(&task); err != nil {
+			utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+
 	utils.ResponseWithJSON(w, http.StatusOK, task)
 }
 
@@ -100,6 +119,10 @@ func (h *TaskHandler) Submit(w http.ResponseWriter, r *http.Request) {
 			utils.ResponseWithError(w, http.StatusNotFound, "task not found")
 			return
 		}
+		if errors.Is(err, service.ErrItemLocked) {
+			utils.ResponseWithError(w, http.StatusForbidden, "complete prerequisites first")
+			return
+		}
 		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
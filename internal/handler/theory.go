@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 
@@ -11,24 +12,36 @@ import (
 )
 
 type TheoryHandler struct {
-	theoryService *service.TheoryService
+	theoryService  *service.TheoryService
+	renderService  *service.RenderService
+	snippetService *service.SnippetService
 }
 
-func NewTheoryHandler(theoryService *service.TheoryService) *TheoryHandler {
-	return &TheoryHandler{theoryService: theoryService}
+func NewTheoryHandler(
+	theoryService *service.TheoryService,
+	renderService *service.RenderService,
+	snippetService *service.SnippetService,
+) *TheoryHandler {
+	return &TheoryHandler{
+		theoryService:  theoryService,
+		renderService:  renderService,
+		snippetService: snippetService,
+	}
 }
 
 func (h *TheoryHandler) ListChapter(w http.ResponseWriter, r *http.Request) {
 	userID := middleware.OptionalUserID(r.Context())
-	chapters := h.theoryService.ListChapters(r.Context(), userID)
+	locale := middleware.LocaleFromContext(r.Context())
+	chapters := h.theoryService.ListChapters(r.Context(), userID, locale)
 	utils.ResponseWithJSON(w, http.StatusOK, chapters)
 }
 
 func (h *TheoryHandler) GetChapter(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "chapterSlug")
 	userID := middleware.OptionalUserID(r.Context())
+	locale := middleware.LocaleFromContext(r.Context())
 
-	chapter, err := h.theoryService.GetChapter(r.Context(), slug, userID)
+	chapter, err := h.theoryService.GetChapter(r.Context(), slug, userID, locale)
 	if err != nil {
 		if errors.Is(err, service.ErrChapterNotFound) {
 			utils.ResponseWithError(w, http.StatusNotFound, "chapter not found")
@@ -45,8 +58,15 @@ func (h *TheoryHandler) GetLesson(w http.ResponseWriter, r *http.Request) {
 	chapterSlug := chi.URLParam(r, "chapterSlug")
 	lessonSlug := chi.URLParam(r, "lessonSlug")
 	userID := middleware.OptionalUserID(r.Context())
+	locale := middleware.LocaleFromContext(r.Context())
+
+	format, err := service.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "unsupported format")
+		return
+	}
 
-	lesson, err := h.theoryService.GetLesson(r.Context(), chapterSlug, lessonSlug, userID)
+	lesson, err := h.theoryService.GetLesson(r.Context(), chapterSlug, lessonSlug, userID, locale)
 	if err != nil {
 		if errors.Is(err, service.ErrLessonNotFound) || errors.Is(err, service.ErrChapterNotFound) {
 			utils.ResponseWithError(w, http.StatusNotFound, "lesson not found")
@@ -56,6 +76,13 @@ func (h *TheoryHandler) GetLesson(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if format == service.FormatHTML {
+		if err := h.renderService.RenderLesson(&lesson); err != nil {
+			utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+	}
+
 	utils.ResponseWithJSON(w, http.StatusOK, lesson)
 }
 
@@ -75,9 +102,60 @@ func (h *TheoryHandler) MarkLessonCompleted(w http.ResponseWriter, r *http.Reque
 			utils.ResponseWithError(w, http.StatusNotFound, "lesson not found")
 			return
 		}
+		if errors.Is(err, service.ErrItemLocked) {
+			utils.ResponseWithError(w, http.StatusForbidden, "complete prerequisites first")
+			return
+		}
+		if errors.Is(err, service.ErrLessonQuizNotPassed) {
+			utils.ResponseWithError(w, http.StatusForbidden, "pass the lesson quiz first")
+			return
+		}
 		utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
 		return
 	}
 
 	utils.ResponseWithJSON(w, http.StatusOK, map[string]string{"message": "lesson marked as completed"})
 }
+
+func (h *TheoryHandler) RunSnippet(w http.ResponseWriter, r *http.Request) {
+	chapterSlug := chi.URLParam(r, "chapterSlug")
+	lessonSlug := chi.URLParam(r, "lessonSlug")
+	snippetID := chi.URLParam(r, "snippetID")
+	locale := middleware.LocaleFromContext(r.Context())
+
+	if _, ok := middleware.UserIDFromContext(r.Context()); !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// тело необязательно: без code запускается исходный пример
+	var req struct {
+		Code string `json:"code"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.ResponseWithError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	if len(req.Code) > 10240 {
+		utils.ResponseWithError(w, http.StatusBadRequest, "code too large")
+		return
+	}
+
+	result, err := h.snippetService.Run(r.Context(), chapterSlug, lessonSlug, snippetID, req.Code, locale)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrChapterNotFound), errors.Is(err, service.ErrLessonNotFound), errors.Is(err, service.ErrSnippetNotFound):
+			utils.ResponseWithError(w, http.StatusNotFound, "snippet not found")
+		case errors.Is(err, service.ErrSnippetNotRunnable):
+			utils.ResponseWithError(w, http.StatusUnprocessableEntity, "snippet is not runnable")
+		default:
+			utils.ResponseWithError(w, http.StatusInternalServerError, "internal error")
+		}
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, result)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/GlebMoskalev/go-path-backend/internal/middleware"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
 	"github.com/GlebMoskalev/go-path-backend/internal/service"
 	"github.com/GlebMoskalev/go-path-backend/internal/utils"
 )
@@ -46,8 +47,11 @@ func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Name    string `json:"name"`
-		Picture string `json:"picture"`
+		Name    string                `json:"name"`
+		Picture string                `json:"picture"`
+		Handle  *string               `json:"handle"`
+		Privacy *model.ProfilePrivacy `json:"privacy"`
+		Locale  *string               `json:"locale"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -60,14 +64,35 @@ func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.userService.UpdateProfile(r.Context(), userID, req.Name, req.Picture); err != nil {
-		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to update profile")
+	err := h.userService.UpdateProfile(r.Context(), userID, model.ProfileUpdate{
+		Name:    req.Name,
+		Picture: req.Picture,
+		Handle:  req.Handle,
+		Privacy: req.Privacy,
+		Locale:  req.Locale,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidHandle):
+			utils.ResponseWithError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrUnsupportedLocale):
+			utils.ResponseWithError(w, http.StatusBadRequest, "unsupported locale")
+		case errors.Is(err, service.ErrHandleReserved):
+			utils.ResponseWithError(w, http.StatusBadRequest, "handle is reserved")
+		case errors.Is(err, service.ErrHandleTaken):
+			utils.ResponseWithError(w, http.StatusConflict, "handle is already taken")
+		case errors.Is(err, service.ErrHandleLimited):
+			utils.ResponseWithError(w, http.StatusTooManyRequests, err.Error())
+		default:
+			utils.ResponseWithError(w, http.StatusInternalServerError, "failed to update profile")
+		}
 		return
 	}
 
 	utils.ResponseWithJSON(w, http.StatusOK, map[string]string{"message": "profile updated successfully"})
 }
 
+// DeleteAccount удаляет аккаунт с отсрочкой: до delete_after его можно восстановить входом
 func (h *UserHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
@@ -75,10 +100,18 @@ func (h *UserHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.userService.DeleteAccount(r.Context(), userID); err != nil {
+	user, err := h.userService.DeleteAccount(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			utils.ResponseWithError(w, http.StatusNotFound, "user not found")
+			return
+		}
 		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to delete account")
 		return
 	}
 
-	utils.ResponseWithJSON(w, http.StatusOK, map[string]string{"message": "account deleted successfully"})
+	utils.ResponseWithJSON(w, http.StatusOK, map[string]any{
+		"message":      "account scheduled for deletion, sign in before delete_after to restore it",
+		"delete_after": user.DeleteAfter,
+	})
 }
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/middleware"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/service"
+	"github.com/GlebMoskalev/go-path-backend/internal/utils"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// ListUsers ищет пользователей: q — подстрока email или имени, role, active=true|false, limit, offset
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := model.UserFilter{
+		Query: query.Get("q"),
+		Role:  model.Role(query.Get("role")),
+	}
+
+	if activeParam := query.Get("active"); activeParam != "" {
+		active, err := strconv.ParseBool(activeParam)
+		if err != nil {
+			utils.ResponseWithError(w, http.StatusBadRequest, "invalid active")
+			return
+		}
+		filter.Active = &active
+	}
+	if limitParam := query.Get("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit < 0 {
+			utils.ResponseWithError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		filter.Limit = limit
+	}
+	if offsetParam := query.Get("offset"); offsetParam != "" {
+		offset, err := strconv.Atoi(offsetParam)
+		if err != nil || offset < 0 {
+			utils.ResponseWithError(w, http.StatusBadRequest, "invalid offset")
+			return
+		}
+		filter.Offset = offset
+	}
+
+	users, err := h.userService.ListUsers(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRole) {
+			utils.ResponseWithError(w, http.StatusBadRequest, "invalid role")
+			return
+		}
+		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to list users")
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, users)
+}
+
+func (h *UserHandler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	actorID, userID, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Role model.Role `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, err := h.userService.ChangeRole(r.Context(), actorID, userID, req.Role)
+	if err != nil {
+		writeAdminUserError(w, err)
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, user)
+}
+
+func (h *UserHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, false)
+}
+
+func (h *UserHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, true)
+}
+
+func (h *UserHandler) setActive(w http.ResponseWriter, r *http.Request, active bool) {
+	actorID, userID, ok := adminTarget(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.SetActive(r.Context(), actorID, userID, active)
+	if err != nil {
+		writeAdminUserError(w, err)
+		return
+	}
+
+	utils.ResponseWithJSON(w, http.StatusOK, user)
+}
+
+// adminTarget достаёт админа из контекста и пользователя из {userID}; при ошибке ответ уже записан
+func adminTarget(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	actorID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ResponseWithError(w, http.StatusUnauthorized, "unauthorized")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid user id")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return actorID, userID, true
+}
+
+func writeAdminUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRole):
+		utils.ResponseWithError(w, http.StatusBadRequest, "invalid role")
+	case errors.Is(err, service.ErrSelfModification):
+		utils.ResponseWithError(w, http.StatusConflict, "cannot change own role or status")
+	case errors.Is(err, service.ErrUserNotFound):
+		utils.ResponseWithError(w, http.StatusNotFound, "user not found")
+	default:
+		utils.ResponseWithError(w, http.StatusInternalServerError, "failed to update user")
+	}
+}
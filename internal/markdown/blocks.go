@@ -0,0 +1,52 @@
+package markdown
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// FencedBlock — огороженный блок кода с info-строкой, например ```go no-run id=counter
+type FencedBlock struct {
+	// Index — номер среди всех блоков кода документа, совпадает с порядком RenderedContent.CodeBlocks
+	Index    int
+	Language string
+	// Attrs — слова info-строки после языка
+	Attrs []string
+	Code  string
+}
+
+// FencedBlocks возвращает огороженные блоки кода документа в порядке появления
+func FencedBlocks(source string) []FencedBlock {
+	src := []byte(source)
+	doc := goldmark.DefaultParser().Parse(text.NewReader(src))
+
+	var blocks []FencedBlock
+	index := 0
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.FencedCodeBlock:
+			var info []string
+			if node.Info != nil {
+				info = strings.Fields(string(node.Info.Segment.Value(src)))
+			}
+			block := FencedBlock{Index: index, Code: blockText(node, src)}
+			if len(info) > 0 {
+				block.Language = info[0]
+				block.Attrs = info[1:]
+			}
+			blocks = append(blocks, block)
+			index++
+		case *ast.CodeBlock:
+			index++
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return blocks
+}
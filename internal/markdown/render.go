@@ -0,0 +1,247 @@
+package markdown
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// wordsPerMinute — средняя скорость чтения технического текста
+const wordsPerMinute = 180
+
+// Renderer превращает markdown контента в безопасный HTML с подсветкой кода.
+// Результат кэшируется по хэшу исходника, т.е. по ревизии контента.
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+
+	mu    sync.RWMutex
+	cache map[[sha256.Size]byte]model.RenderedContent
+}
+
+func NewRenderer() *Renderer {
+	policy := bluemonday.UGCPolicy()
+	policy.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	// классы, которые ставит подсветка chroma
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^[a-zA-Z0-9 _-]+$`)).OnElements("pre", "code", "span")
+
+	return &Renderer{
+		md: goldmark.New(
+			goldmark.WithExtensions(extension.GFM),
+			goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+			goldmark.WithRendererOptions(renderer.WithNodeRenderers(util.Prioritized(&codeRenderer{}, 100))),
+		),
+		policy: policy,
+		cache:  make(map[[sha256.Size]byte]model.RenderedContent),
+	}
+}
+
+// Render возвращает HTML, оглавление, время чтения и блоки кода документа
+func (r *Renderer) Render(source string) (model.RenderedContent, error) {
+	key := sha256.Sum256([]byte(source))
+
+	r.mu.RLock()
+	rendered, ok := r.cache[key]
+	r.mu.RUnlock()
+	if ok {
+		return rendered, nil
+	}
+
+	src := []byte(source)
+	// якоря считаются заново для каждого документа
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	doc := r.md.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	var buf bytes.Buffer
+	if err := r.md.Renderer().Render(&buf, src, doc); err != nil {
+		return model.RenderedContent{}, err
+	}
+
+	rendered = model.RenderedContent{
+		HTML:        r.policy.Sanitize(buf.String()),
+		TOC:         []model.TOCEntry{},
+		CodeBlocks:  []model.CodeBlock{},
+		ReadingTime: readingTime(source),
+	}
+
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Heading:
+			id, _ := node.AttributeString("id")
+			idBytes, _ := id.([]byte)
+			rendered.TOC = append(rendered.TOC, model.TOCEntry{
+				Level: node.Level,
+				ID:    string(idBytes),
+				Title: inlineText(node, src),
+			})
+			return ast.WalkSkipChildren, nil
+		case *ast.FencedCodeBlock:
+			rendered.CodeBlocks = append(rendered.CodeBlocks, model.CodeBlock{
+				Language: string(node.Language(src)),
+				Code:     blockText(node, src),
+			})
+		case *ast.CodeBlock:
+			rendered.CodeBlocks = append(rendered.CodeBlocks, model.CodeBlock{Code: blockText(node, src)})
+		}
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		return model.RenderedContent{}, err
+	}
+
+	r.mu.Lock()
+	r.cache[key] = rendered
+	r.mu.Unlock()
+
+	return rendered, nil
+}
+
+// readingTime — минуты чтения с округлением вверх, не меньше одной
+func readingTime(source string) int {
+	words := len(strings.Fields(source))
+	minutes := (words + wordsPerMinute - 1) / wordsPerMinute
+	return max(minutes, 1)
+}
+
+func blockText(n ast.Node, src []byte) string {
+	var b strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		b.Write(seg.Value(src))
+	}
+	return b.String()
+}
+
+func inlineText(n ast.Node, src []byte) string {
+	var b strings.Builder
+	_ = ast.Walk(n, func(child ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch c := child.(type) {
+		case *ast.Text:
+			b.Write(c.Segment.Value(src))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(c.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
+
+// codeRenderer подсвечивает блоки кода через chroma; классы вместо inline-стилей,
+// чтобы клиент сам выбирал тему и чтобы HTML проходил санитайзер
+type codeRenderer struct{}
+
+func (c *codeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, c.render)
+	reg.Register(ast.KindCodeBlock, c.render)
+}
+
+func (c *codeRenderer) render(w util.BufWriter, src []byte, n ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	var lang string
+	if fenced, ok := n.(*ast.FencedCodeBlock); ok {
+		lang = string(fenced.Language(src))
+	}
+	code := blockText(n, src)
+
+	lexer := lexers.Get(lang)
+	if lang == "" || lexer == nil {
+		w.WriteString("<pre><code>")
+		w.WriteString(html.EscapeString(code))
+		w.WriteString("</code></pre>\n")
+		return ast.WalkSkipChildren, nil
+	}
+
+	iterator, err := lexer.Tokenise(nil, code)
+	if err != nil {
+		return ast.WalkStop, err
+	}
+	formatter := chromahtml.New(chromahtml.WithClasses(true))
+	if err := formatter.Format(w, styles.Fallback, iterator); err != nil {
+		return ast.WalkStop, err
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+// headingIDs строит якоря заголовков с сохранением кириллицы:
+// "Что такое горутина?" -> "что-такое-горутина"; повторы получают суффикс -1, -2, ...
+type headingIDs struct {
+	seen map[string]int
+}
+
+func newHeadingIDs() parser.IDs {
+	return &headingIDs{seen: make(map[string]int)}
+}
+
+func (h *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(string(value)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		default:
+			dash = true
+		}
+	}
+
+	id := b.String()
+	if id == "" {
+		id = "section"
+	}
+	return []byte(h.unique(id))
+}
+
+func (h *headingIDs) Put(value []byte) {
+	h.seen[string(value)]++
+}
+
+func (h *headingIDs) unique(id string) string {
+	n, ok := h.seen[id]
+	h.seen[id] = n + 1
+	if !ok {
+		return id
+	}
+	for {
+		candidate := id + "-" + strconv.Itoa(n)
+		if _, taken := h.seen[candidate]; !taken {
+			h.seen[candidate] = 1
+			return candidate
+		}
+		n++
+	}
+}
@@ -4,25 +4,35 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
 	"github.com/GlebMoskalev/go-path-backend/internal/service"
 	"github.com/google/uuid"
 )
 
 type contextKey string
 
-const userIDKey contextKey = "user_id"
+const (
+	userIDKey    contextKey = "user_id"
+	sessionIDKey contextKey = "session_id"
+	roleKey      contextKey = "role"
+	// scopeKey — право, с которым маршрут принимает personal access token (см. TokenScope)
+	scopeKey contextKey = "token_scope"
+)
 
 type AuthMiddleware struct {
-	authService *service.AuthService
-	userService *service.UserService
+	authService  *service.AuthService
+	userService  *service.UserService
+	tokenService *service.PersonalTokenService
 }
 
-func NewAuthMiddleware(authService *service.AuthService, userService *service.UserService) *AuthMiddleware {
+func NewAuthMiddleware(authService *service.AuthService, userService *service.UserService, tokenService *service.PersonalTokenService) *AuthMiddleware {
 	return &AuthMiddleware{
-		authService: authService,
-		userService: userService,
+		authService:  authService,
+		userService:  userService,
+		tokenService: tokenService,
 	}
 }
 
@@ -43,13 +53,23 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 
 		token := parts[1]
 
-		userID, tokenVersion, err := m.authService.ValidateAccessToken(r.Context(), token)
+		if service.IsPersonalToken(token) {
+			ctx, status, message := m.personalTokenContext(r, token)
+			if status != 0 {
+				http.Error(w, message, status)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		claims, err := m.authService.ValidateAccessToken(r.Context(), token)
 		if err != nil {
 			http.Error(w, "invalid or expired token", http.StatusUnauthorized)
 			return
 		}
 
-		user, err := m.userService.GetByID(r.Context(), userID)
+		state, err := m.userService.GetAuthState(r.Context(), claims.UserID)
 		if err != nil {
 			if errors.Is(err, service.ErrUserNotFound) {
 				http.Error(w, "user not found", http.StatusUnauthorized)
@@ -59,12 +79,30 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		if tokenVersion != user.TokenVersion {
+		if claims.TokenVersion != state.TokenVersion {
 			http.Error(w, "token has been invalidated", http.StatusUnauthorized)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), userIDKey, userID)
+		revoked, err := m.authService.SessionRevoked(r.Context(), claims.SessionID)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if revoked {
+			http.Error(w, "session has been revoked", http.StatusUnauthorized)
+			return
+		}
+
+		if !state.IsActive {
+			http.Error(w, "account is deactivated", http.StatusForbidden)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
+		ctx = context.WithValue(ctx, sessionIDKey, claims.SessionID)
+		ctx = context.WithValue(ctx, roleKey, state.Role)
+		ctx = withUserLocale(ctx, r, state.Locale)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -76,13 +114,27 @@ func (m *AuthMiddleware) OptionalAuthenticate(next http.Handler) http.Handler {
 
 		if len(parts) == 2 && parts[0] == "Bearer" {
 			token := parts[1]
-			userID, tokenVersion, err := m.authService.ValidateAccessToken(r.Context(), token)
-			if err == nil {
-				user, err := m.userService.GetByID(r.Context(), userID)
-				if err == nil && tokenVersion == user.TokenVersion {
-					ctx := context.WithValue(r.Context(), userIDKey, userID)
+			if service.IsPersonalToken(token) {
+				if ctx, status, _ := m.personalTokenContext(r, token); status == 0 {
 					r = r.WithContext(ctx)
 				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			claims, err := m.authService.ValidateAccessToken(r.Context(), token)
+			if err == nil {
+				state, err := m.userService.GetAuthState(r.Context(), claims.UserID)
+				if err == nil && claims.TokenVersion == state.TokenVersion && state.IsActive {
+					revoked, err := m.authService.SessionRevoked(r.Context(), claims.SessionID)
+					if err == nil && !revoked {
+						ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
+						ctx = context.WithValue(ctx, sessionIDKey, claims.SessionID)
+						ctx = context.WithValue(ctx, roleKey, state.Role)
+						ctx = withUserLocale(ctx, r, state.Locale)
+						r = r.WithContext(ctx)
+					}
+				}
 			}
 		}
 
@@ -90,11 +142,64 @@ func (m *AuthMiddleware) OptionalAuthenticate(next http.Handler) http.Handler {
 	})
 }
 
+// personalTokenContext аутентифицирует personal access token. Токен принимается только
+// маршрутами с TokenScope и только если у него есть это право; при отказе возвращает статус и текст.
+func (m *AuthMiddleware) personalTokenContext(r *http.Request, token string) (context.Context, int, string) {
+	scope, ok := r.Context().Value(scopeKey).(model.Scope)
+	if !ok {
+		return nil, http.StatusForbidden, "personal access tokens are not accepted for this endpoint"
+	}
+
+	pt, err := m.tokenService.Authenticate(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPersonalToken) {
+			return nil, http.StatusUnauthorized, "invalid or expired token"
+		}
+		return nil, http.StatusInternalServerError, "internal error"
+	}
+	if !slices.Contains(pt.Scopes, scope) {
+		return nil, http.StatusForbidden, "token lacks scope " + string(scope)
+	}
+
+	state, err := m.userService.GetAuthState(r.Context(), pt.UserID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, http.StatusUnauthorized, "user not found"
+		}
+		return nil, http.StatusInternalServerError, "internal error"
+	}
+	if !state.IsActive {
+		return nil, http.StatusForbidden, "account is deactivated"
+	}
+
+	ctx := context.WithValue(r.Context(), userIDKey, pt.UserID)
+	ctx = context.WithValue(ctx, roleKey, state.Role)
+	ctx = withUserLocale(ctx, r, state.Locale)
+	return ctx, 0, ""
+}
+
+// TokenScope разрешает маршрутам группы personal access token с правом scope.
+// Ставится перед Authenticate/OptionalAuthenticate; без него такие токены отклоняются.
+func TokenScope(scope model.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), scopeKey, scope)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 	value, ok := ctx.Value(userIDKey).(uuid.UUID)
 	return value, ok
 }
 
+// SessionIDFromContext — сессия access token запроса; uuid.Nil у токенов без сессии
+func SessionIDFromContext(ctx context.Context) uuid.UUID {
+	value, _ := ctx.Value(sessionIDKey).(uuid.UUID)
+	return value
+}
+
 func OptionalUserID(ctx context.Context) *uuid.UUID {
 	if id, ok := UserIDFromContext(ctx); ok {
 		return &id
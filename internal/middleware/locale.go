@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+)
+
+const localeKey contextKey = "locale"
+
+// Locale определяет язык контента: параметр ?lang= (явный выбор для запроса),
+// затем Accept-Language, иначе model.DefaultLocale. Для авторизованных запросов
+// Authenticate заменяет Accept-Language языком из профиля, см. withUserLocale
+func Locale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := r.URL.Query().Get("lang")
+		if !model.IsSupportedLocale(locale) {
+			locale = negotiateLocale(r.Header.Get("Accept-Language"))
+		}
+
+		// ответ зависит от Accept-Language: кэши не должны отдавать его другой локали
+		w.Header().Add("Vary", "Accept-Language")
+
+		ctx := context.WithValue(r.Context(), localeKey, locale)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// withUserLocale подставляет язык из профиля, если запрос не выбрал язык через ?lang=
+func withUserLocale(ctx context.Context, r *http.Request, locale string) context.Context {
+	if !model.IsSupportedLocale(locale) || model.IsSupportedLocale(r.URL.Query().Get("lang")) {
+		return ctx
+	}
+	return context.WithValue(ctx, localeKey, locale)
+}
+
+func LocaleFromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey).(string); ok {
+		return locale
+	}
+	return model.DefaultLocale
+}
+
+func negotiateLocale(header string) string {
+	type candidate struct {
+		locale string
+		q      float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		lang, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if q > 0 && model.IsSupportedLocale(lang) {
+			candidates = append(candidates, candidate{locale: lang, q: q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return model.DefaultLocale
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].locale
+}
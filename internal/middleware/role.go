@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+)
+
+// RequireRole пропускает пользователя с одной из ролей; ставится после Authenticate.
+// Роль берётся из базы при аутентификации, поэтому смена роли действует сразу.
+func RequireRole(roles ...model.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(roles, RoleFromContext(r.Context())) {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RoleFromContext — роль аутентифицированного пользователя; пустая строка без аутентификации
+func RoleFromContext(ctx context.Context) model.Role {
+	value, _ := ctx.Value(roleKey).(model.Role)
+	return value
+}
@@ -1,5 +1,11 @@
 package model
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
@@ -17,3 +23,112 @@ type GoogleUserInfo struct {
 	Picture       string `json:"picture"`
 	Locale        string `json:"locale"`
 }
+
+type GitHubUserInfo struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// GitHubEmail — элемент ответа /user/emails; публичный email в /user может быть пустым
+type GitHubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// OAuthProfile — профиль пользователя у провайдера, приведённый к общему виду
+type OAuthProfile struct {
+	Provider string
+	// Subject — постоянный id пользователя у провайдера
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
+
+// UserIdentity — аккаунт провайдера, привязанный к пользователю
+type UserIdentity struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"-"`
+	UserID    uuid.UUID `json:"-"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OAuthState хранится под state до возврата с провайдера
+type OAuthState struct {
+	Provider string `json:"provider"`
+	// LinkUserID задан, если вход начат для привязки провайдера к этому пользователю
+	LinkUserID *uuid.UUID `json:"link_user_id,omitempty"`
+	// CodeChallenge — PKCE challenge (S256) из запроса входа; переходит в AuthCode
+	CodeChallenge string `json:"code_challenge,omitempty"`
+}
+
+// AuthCode — одноразовый код из редиректа на фронтенд, обменивается на TokenPair
+type AuthCode struct {
+	UserID        uuid.UUID `json:"user_id"`
+	CodeChallenge string    `json:"code_challenge,omitempty"`
+}
+
+// PendingLink — аккаунт провайдера, вернувшийся из потока привязки. Привязывается, только
+// когда пользователь LinkUserID подтвердит код своим токеном: ссылку на провайдера
+// можно подсунуть другому человеку, и без подтверждения привязался бы его аккаунт.
+type PendingLink struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Provider string    `json:"provider"`
+	Subject  string    `json:"subject"`
+	Email    string    `json:"email"`
+}
+
+// Session — вход с одного устройства. Refresh-токены сессии образуют семейство:
+// действителен только последний выданный (RefreshJTI), повтор старого отзывает сессию.
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"-"`
+	RefreshJTI uuid.UUID  `json:"-"`
+	Device     string     `json:"device"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+	// Current — сессия, которой принадлежит access token запроса
+	Current bool `json:"current"`
+}
+
+// ClientInfo — данные устройства из запроса, с которого выдаются или обновляются токены
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// AccessClaims — проверенное содержимое access token
+type AccessClaims struct {
+	UserID       uuid.UUID
+	TokenVersion int
+	// SessionID пуст у токенов, выданных до появления сессий
+	SessionID uuid.UUID
+	// Role — роль на момент выдачи токена, для сервисов, проверяющих токен по JWKS
+	Role Role
+}
+
+// JWK — публичный ключ проверки токенов в формате RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// ExportJob — состояние выгрузки данных пользователя; ссылка на архив живёт до ExpiresAt
+type ExportJob struct {
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// UserExport — export.json в архиве: всё, что хранится о пользователе.
+// Анализы AI не сохраняются и в выгрузку не попадают.
+type UserExport struct {
+	ExportedAt     time.Time           `json:"exported_at"`
+	Profile        User                `json:"profile"`
+	Identities     []UserIdentity      `json:"identities"`
+	Sessions       []Session           `json:"sessions"`
+	PersonalTokens []PersonalToken     `json:"personal_tokens"`
+	TheoryProgress []LessonProgress    `json:"theory_progress"`
+	Submissions    []ExportSubmission  `json:"submissions"`
+	QuizAttempts   []ExportQuizAttempt `json:"quiz_attempts"`
+	QuizReviews    []ExportQuizReview  `json:"quiz_reviews"`
+}
+
+type LessonProgress struct {
+	ChapterSlug string    `json:"chapter_slug"`
+	LessonSlug  string    `json:"lesson_slug"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// ExportSubmission — посылка с путём к файлу решения в архиве и историей перепроверок
+type ExportSubmission struct {
+	Submission
+	File     string    `json:"file"`
+	Rejudges []Rejudge `json:"rejudges,omitempty"`
+}
+
+type ExportQuizAttempt struct {
+	ID         uuid.UUID          `json:"id"`
+	Score      float64            `json:"score"`
+	Correct    int                `json:"correct"`
+	Total      int                `json:"total"`
+	Answers    []ExportQuizAnswer `json:"answers"`
+	StartedAt  time.Time          `json:"started_at"`
+	FinishedAt *time.Time         `json:"finished_at"`
+}
+
+type ExportQuizAnswer struct {
+	QuestionID string   `json:"question_id"`
+	Answer     []string `json:"answer"`
+	Correct    bool     `json:"correct"`
+	Score      float64  `json:"score"`
+}
+
+type ExportQuizReview struct {
+	QuestionID   string    `json:"question_id"`
+	Ease         float64   `json:"ease"`
+	IntervalDays int       `json:"interval_days"`
+	Repetitions  int       `json:"repetitions"`
+	DueAt        time.Time `json:"due_at"`
+	ReviewedAt   time.Time `json:"reviewed_at"`
+}
@@ -0,0 +1,19 @@
+package model
+
+const (
+	LocaleRu      = "ru"
+	LocaleEn      = "en"
+	DefaultLocale = LocaleRu
+)
+
+// SupportedLocales — языки контента, DefaultLocale идёт первым
+var SupportedLocales = []string{LocaleRu, LocaleEn}
+
+func IsSupportedLocale(locale string) bool {
+	for _, l := range SupportedLocales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
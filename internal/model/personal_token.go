@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scope — право personal access token; JWT из браузера ограничений не имеет
+type Scope string
+
+const (
+	ScopeSubmissionsWrite Scope = "submissions:write"
+	ScopeProgressRead     Scope = "progress:read"
+	ScopeProfileRead      Scope = "profile:read"
+)
+
+var Scopes = []Scope{ScopeSubmissionsWrite, ScopeProgressRead, ScopeProfileRead}
+
+// PersonalToken — токен для CLI и редакторов; в базе хранится только SHA-256 от значения
+type PersonalToken struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"-"`
+	Name   string    `json:"name"`
+	// Prefix — начало токена, чтобы узнать его в списке
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []Scope    `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedPersonalToken возвращается один раз при создании: значение токена больше нигде не показывается
+type CreatedPersonalToken struct {
+	PersonalToken
+	Token string `json:"token"`
+}
@@ -13,6 +13,9 @@ type StepFrontmatter struct {
 	Difficulty  string   `yaml:"difficulty"`
 	File        string   `yaml:"file"`
 	Hints       []string `yaml:"hints"`
+	Requires    []string `yaml:"requires"`
+	// RelatedLessons — уроки в формате "chapter/lesson"
+	RelatedLessons []string `yaml:"related_lessons"`
 }
 
 type Project struct {
@@ -22,22 +25,31 @@ type Project struct {
 	Order       int           `json:"order"`
 	Steps       []ProjectStep `json:"steps"`
 	SolvedCount int           `json:"solved_count"`
+	Locale      string        `json:"locale"`
 }
 
 type ProjectStep struct {
-	Slug         string       `json:"slug"`
-	Title        string       `json:"title"`
-	Description  string       `json:"description,omitempty"`
-	Template     string       `json:"template,omitempty"`
-	Difficulty   string       `json:"difficulty"`
-	Hints        []string     `json:"hints,omitempty"`
-	File         string       `json:"file,omitempty"`
-	Order        int          `json:"order"`
-	ProjectSlug  string       `json:"project_slug,omitempty"`
-	ProjectTitle string       `json:"project_title,omitempty"`
-	Solved       *bool        `json:"solved,omitempty"`
-	Completions  []Completion `json:"completions"`
-	Submissions  []Submission `json:"submissions"`
+	Slug         string   `json:"slug"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description,omitempty"`
+	Template     string   `json:"template,omitempty"`
+	Difficulty   string   `json:"difficulty"`
+	Hints        []string `json:"hints,omitempty"`
+	File         string   `json:"file,omitempty"`
+	Order        int      `json:"order"`
+	ProjectSlug  string   `json:"project_slug,omitempty"`
+	ProjectTitle string   `json:"project_title,omitempty"`
+	Locale       string   `json:"locale"`
+	Requires     []string `json:"requires,omitempty"`
+	// RelatedLessons заполняется только для одного шага, в списках отсутствует
+	RelatedLessons []LessonLink `json:"related_lessons,omitempty"`
+	// Rendered заполняется при ?format=html, Description тогда пустой
+	Rendered *RenderedContent `json:"rendered,omitempty"`
+	Solved   *bool            `json:"solved,omitempty"`
+	// Locked: nil = не авторизован, true = не пройдены зависимости из Requires
+	Locked      *bool        `json:"locked,omitempty"`
+	Completions []Completion `json:"completions"`
+	Submissions []Submission `json:"submissions"`
 }
 
 type FormatContext struct {
@@ -1,22 +1,204 @@
 package model
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Типы вопросов квиза; пустой type в YAML означает QuizTypeSingle
+const (
+	QuizTypeSingle = "single"
+	QuizTypeMulti  = "multi"
+	QuizTypeText   = "text"
+	QuizTypeOrder  = "order"
+)
+
 type QuizQuestion struct {
-	ID          string   `json:"id" yaml:"-"`
-	Question    string   `json:"question" yaml:"question"`
-	Options     []string `json:"options" yaml:"options"`
-	Answer      int      `json:"-" yaml:"answer"`
-	Explanation string   `json:"-" yaml:"explanation"`
-	ChapterSlug string   `json:"chapter_slug" yaml:"-"`
+	// ID — "глава:id"; id берётся из YAML или из хэша текста вопроса и кода
+	ID       string `json:"id" yaml:"id"`
+	Type     string `json:"type" yaml:"type"`
+	Question string `json:"question" yaml:"question"`
+	// Lesson — slug урока главы для мини-квиза в конце урока; пусто — вопрос только главы
+	Lesson string `json:"lesson,omitempty" yaml:"lesson"`
+	// Code — программа для вопросов «что выведет код»; верный вариант проверяется запуском в песочнице
+	Code    string       `json:"code,omitempty" yaml:"code"`
+	Options []QuizOption `json:"options,omitempty" yaml:"options"`
+	// Answer — индекс верного варианта для single
+	Answer int `json:"-" yaml:"answer"`
+	// Answers — индексы всех верных вариантов для multi
+	Answers []int `json:"-" yaml:"answers"`
+	// Accept — правила приёма свободного ответа для text; достаточно совпадения с одним
+	Accept []QuizTextAccept `json:"-" yaml:"accept"`
+	// Lines — строки в правильном порядке для order; клиент получает их перемешанными в Options
+	Lines       []QuizOption `json:"-" yaml:"lines"`
+	Explanation string       `json:"-" yaml:"explanation"`
+	ChapterSlug string       `json:"chapter_slug" yaml:"-"`
+	Locale      string       `json:"locale" yaml:"-"`
+	// Revision — хэш текста, кода, вариантов и верного ответа; меняется при правке вопроса
+	Revision string `json:"-" yaml:"-"`
+}
+
+// QuizOption — вариант ответа или строка вопроса order. ID не зависит от позиции:
+// ответы сверяются по нему, а не по тексту перемешанного варианта.
+type QuizOption struct {
+	ID   string `json:"id" yaml:"id"`
+	Text string `json:"text" yaml:"text"`
+}
+
+// UnmarshalYAML принимает и старую запись варианта строкой, и {id, text}
+func (o *QuizOption) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		o.Text = node.Value
+		return nil
+	}
+	type plain QuizOption
+	return node.Decode((*plain)(o))
+}
+
+// QuizTextAccept — одно правило: точное совпадение, регулярное выражение или число с допуском
+type QuizTextAccept struct {
+	Exact     string   `yaml:"exact"`
+	Regex     string   `yaml:"regex"`
+	Number    *float64 `yaml:"number"`
+	Tolerance float64  `yaml:"tolerance"`
 }
 
 type QuizChapterInfo struct {
 	Slug          string `json:"slug"`
 	Title         string `json:"title"`
 	QuestionCount int    `json:"question_count"`
+	Locale        string `json:"locale"`
 }
 
 type QuizAnswerResponse struct {
-	Correct       bool   `json:"correct"`
-	CorrectAnswer string `json:"correct_answer"`
-	Explanation   string `json:"explanation"`
+	Correct bool `json:"correct"`
+	// Score — доля от 0 до 1; частичный балл бывает у multi и order
+	Score          float64  `json:"score"`
+	CorrectAnswer  string   `json:"correct_answer,omitempty"`
+	CorrectAnswers []string `json:"correct_answers,omitempty"`
+	// CorrectOptionIDs — ID верных вариантов; у order — ID строк в верном порядке
+	CorrectOptionIDs []string `json:"correct_option_ids,omitempty"`
+	Explanation      string   `json:"explanation"`
+}
+
+// QuizRunResponse — проверка ответа и настоящий вывод кода вопроса
+type QuizRunResponse struct {
+	QuizAnswerResponse
+	Run RunResult `json:"run"`
+}
+
+// QuizAttempt — прохождение квиза: набор вопросов фиксируется при старте
+type QuizAttempt struct {
+	ID          uuid.UUID `json:"id"`
+	UserID      uuid.UUID `json:"-"`
+	QuestionIDs []string  `json:"-"`
+	// Questions заполняется только в ответе на старт
+	Questions []QuizQuestion `json:"questions,omitempty"`
+	// Score — сумма баллов за ответы, делённая на число вопросов попытки
+	Score      float64             `json:"score"`
+	Correct    int                 `json:"correct"`
+	Answered   int                 `json:"answered"`
+	Total      int                 `json:"total"`
+	Answers    []QuizAttemptAnswer `json:"-"`
+	StartedAt  time.Time           `json:"started_at"`
+	FinishedAt *time.Time          `json:"finished_at"`
+}
+
+type QuizAttemptAnswer struct {
+	QuestionID string
+	Answer     []string
+	Correct    bool
+	Score      float64
+}
+
+type QuizStats struct {
+	Attempts          int                `json:"attempts"`
+	AnsweredQuestions int                `json:"answered_questions"`
+	Accuracy          float64            `json:"accuracy"`
+	LastAttemptAt     *time.Time         `json:"last_attempt_at"`
+	Chapters          []QuizChapterStats `json:"chapters"`
+}
+
+type QuizChapterStats struct {
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+	// BestScore — лучшая доля баллов по вопросам главы среди завершённых попыток
+	BestScore     float64    `json:"best_score"`
+	Attempts      int        `json:"attempts"`
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+}
+
+// QuizReviewCard — расписание повторения вопроса по SM-2
+type QuizReviewCard struct {
+	QuestionID   string
+	Revision     string
+	Ease         float64
+	IntervalDays int
+	Repetitions  int
+	DueAt        time.Time
+	ReviewedAt   time.Time
+}
+
+type QuizReviewItem struct {
+	Question    QuizQuestion `json:"question"`
+	DueAt       time.Time    `json:"due_at"`
+	Repetitions int          `json:"repetitions"`
+}
+
+type QuizReviewResponse struct {
+	QuizAnswerResponse
+	DueAt        time.Time `json:"due_at"`
+	IntervalDays int       `json:"interval_days"`
+}
+
+// QuizExamSession — экзамен: набор вопросов и порядок вариантов фиксируются при старте,
+// ответы до сдачи хранятся отдельно и не проверяются
+type QuizExamSession struct {
+	ID          string    `json:"id"`
+	UserID      uuid.UUID `json:"user_id"`
+	QuestionIDs []string  `json:"question_ids"`
+	// OptionOrder: question id -> ID вариантов в порядке показа, чтобы обновление страницы их не перемешивало
+	OptionOrder map[string][]string `json:"option_order"`
+	StartedAt   time.Time           `json:"started_at"`
+	ExpiresAt   time.Time           `json:"expires_at"`
+}
+
+// QuizExamView — состояние экзамена для клиента; Report появляется только после сдачи
+type QuizExamView struct {
+	Token            string              `json:"token"`
+	Questions        []QuizQuestion      `json:"questions"`
+	Answers          map[string][]string `json:"answers"`
+	ExpiresAt        time.Time           `json:"expires_at"`
+	RemainingSeconds int                 `json:"remaining_seconds"`
+	Report           *QuizExamReport     `json:"report,omitempty"`
+}
+
+type QuizExamReport struct {
+	Score    float64 `json:"score"`
+	Correct  int     `json:"correct"`
+	Answered int     `json:"answered"`
+	Total    int     `json:"total"`
+	// Late — сдан после окончания времени; ответы после лимита не принимались
+	Late       bool             `json:"late"`
+	FinishedAt time.Time        `json:"finished_at"`
+	Results    []QuizExamResult `json:"results"`
+}
+
+type QuizExamResult struct {
+	QuestionID string   `json:"question_id"`
+	Answer     []string `json:"answer"`
+	QuizAnswerResponse
+}
+
+// LessonQuiz — мини-квиз в конце урока: вопросы главы с lesson: этого урока
+type LessonQuiz struct {
+	ChapterSlug string         `json:"chapter_slug"`
+	LessonSlug  string         `json:"lesson_slug"`
+	Questions   []QuizQuestion `json:"questions"`
+	// PassScore — доля верных ответов для прохождения
+	PassScore float64 `json:"pass_score"`
+	// Passed: nil = не авторизован; считается по последним ответам на вопросы урока
+	Passed *bool `json:"passed,omitempty"`
 }
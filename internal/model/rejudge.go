@@ -0,0 +1,75 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RejudgeKindTask    = "task"
+	RejudgeKindProject = "project"
+)
+
+const (
+	RejudgeRunning = "running"
+	RejudgeDone    = "done"
+	RejudgeFailed  = "failed"
+)
+
+type RejudgeRequest struct {
+	// Kind: RejudgeKindTask или RejudgeKindProject
+	Kind        string `json:"-"`
+	ChapterSlug string `json:"-"`
+	TaskSlug    string `json:"-"`
+	// All: true = все попытки, false = только последняя попытка каждого пользователя
+	All    bool   `json:"all"`
+	DryRun bool   `json:"dry_run"`
+	Reason string `json:"reason"`
+}
+
+type Rejudge struct {
+	ID           uuid.UUID    `json:"id"`
+	SubmissionID uuid.UUID    `json:"submission_id"`
+	Reason       string       `json:"reason"`
+	OldPassed    bool         `json:"old_passed"`
+	NewPassed    bool         `json:"new_passed"`
+	OldResult    SubmitResult `json:"old_result"`
+	NewResult    SubmitResult `json:"new_result"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+type RejudgeReport struct {
+	Kind         string               `json:"kind"`
+	ChapterSlug  string               `json:"chapter_slug"`
+	TaskSlug     string               `json:"task_slug"`
+	DryRun       bool                 `json:"dry_run"`
+	Total        int                  `json:"total"`
+	Changed      int                  `json:"changed"`
+	Errors       int                  `json:"errors"`
+	GainedSolved int                  `json:"gained_solved"`
+	LostSolved   int                  `json:"lost_solved"`
+	Submissions  []RejudgedSubmission `json:"submissions"`
+}
+
+// RejudgeJob — перепроверка, запущенная из админки в фоне; при сбое Report содержит
+// то, что успели перепроверить и сохранить до ошибки
+type RejudgeJob struct {
+	ID          uuid.UUID      `json:"id"`
+	Status      string         `json:"status"`
+	Kind        string         `json:"kind"`
+	ChapterSlug string         `json:"chapter_slug"`
+	TaskSlug    string         `json:"task_slug"`
+	StartedAt   time.Time      `json:"started_at"`
+	FinishedAt  *time.Time     `json:"finished_at,omitempty"`
+	Report      *RejudgeReport `json:"report,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+type RejudgedSubmission struct {
+	SubmissionID uuid.UUID `json:"submission_id"`
+	UserID       uuid.UUID `json:"user_id"`
+	OldPassed    bool      `json:"old_passed"`
+	NewPassed    bool      `json:"new_passed"`
+	Error        string    `json:"error,omitempty"`
+}
@@ -0,0 +1,24 @@
+package model
+
+const (
+	SearchTypeLesson = "lesson"
+	SearchTypeTask   = "task"
+	SearchTypeQuiz   = "quiz"
+	SearchTypeStep   = "step"
+)
+
+type SearchResult struct {
+	Type string `json:"type"`
+	// ChapterSlug — глава урока, задачи или вопроса либо slug проекта для шага
+	ChapterSlug string  `json:"chapter_slug"`
+	Slug        string  `json:"slug"`
+	Title       string  `json:"title"`
+	Snippet     string  `json:"snippet"`
+	Score       float64 `json:"score"`
+	Locale      string  `json:"locale"`
+}
+
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+}
@@ -4,6 +4,7 @@ type UserStats struct {
 	Theory   TheoryStats   `json:"theory"`
 	Tasks    TasksStats    `json:"tasks"`
 	Projects ProjectsStats `json:"projects"`
+	Quiz     QuizStats     `json:"quiz"`
 }
 
 type TheoryStats struct {
@@ -18,6 +18,9 @@ type TaskFrontmatter struct {
 	Order       int      `yaml:"order"`
 	Difficulty  string   `yaml:"difficulty"`
 	Hints       []string `yaml:"hints"`
+	Requires    []string `yaml:"requires"`
+	// RelatedLessons — уроки в формате "chapter/lesson"
+	RelatedLessons []string `yaml:"related_lessons"`
 }
 
 type TaskChapter struct {
@@ -27,6 +30,7 @@ type TaskChapter struct {
 	Order       int    `json:"order"`
 	Tasks       []Task `json:"tasks"`
 	SolvedCount int    `json:"solved_count"`
+	Locale      string `json:"locale"`
 }
 
 type Task struct {
@@ -39,8 +43,16 @@ type Task struct {
 	Order        int      `json:"order"`
 	ChapterSlug  string   `json:"chapter_slug,omitempty"`
 	ChapterTitle string   `json:"chapter_title,omitempty"`
+	Locale       string   `json:"locale"`
+	Requires     []string `json:"requires,omitempty"`
+	// RelatedLessons заполняется только для одной задачи, в списках отсутствует
+	RelatedLessons []LessonLink `json:"related_lessons,omitempty"`
+	// Rendered заполняется при ?format=html, Description тогда пустой
+	Rendered *RenderedContent `json:"rendered,omitempty"`
 	// Solved: nil = не авторизован, true/false = авторизован
 	Solved *bool `json:"solved,omitempty"`
+	// Locked: nil = не авторизован, true = не пройдены зависимости из Requires
+	Locked *bool `json:"locked,omitempty"`
 	// Submissions: nil = не авторизован, []Submission = авторизован
 	Submissions []Submission `json:"submissions"`
 	Completions []Completion `json:"completions"`
@@ -73,3 +85,10 @@ type SolvedTask struct {
 	ChapterSlug string `json:"chapter_slug"`
 	TaskSlug    string `json:"task_slug"`
 }
+
+// RunResult — вывод программы, запущенной в песочнице через go run
+type RunResult struct {
+	Output   string `json:"output"`
+	Error    string `json:"error,omitempty"`
+	ExitCode int64  `json:"exit_code"`
+}
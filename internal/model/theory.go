@@ -6,6 +6,7 @@ type Chapter struct {
 	Description string   `json:"description"`
 	Order       int      `json:"order"`
 	Lessons     []Lesson `json:"lessons"`
+	Locale      string   `json:"locale"`
 	// Progress nil = не авторизован, иначе статистика
 	Progress *ChapterProgress `json:"progress,omitempty"`
 }
@@ -23,8 +24,53 @@ type Lesson struct {
 	ChapterSlug  string `json:"chapter_slug,omitempty"`
 	ChapterTitle string `json:"chapter_title,omitempty"`
 	Content      string `json:"content,omitempty"`
+	Locale       string `json:"locale"`
+	// Requires — ссылки на уроки, задачи и шаги, которые нужно пройти до этого урока
+	Requires []string `json:"requires,omitempty"`
 	// Completed: nil = не авторизован, true/false = авторизован
 	Completed *bool `json:"completed,omitempty"`
+	// Locked: nil = не авторизован, true = не пройдены зависимости из Requires
+	Locked *bool `json:"locked,omitempty"`
+	// PracticeTasks — задачи и шаги проектов, ссылающиеся на урок через related_lessons
+	PracticeTasks []PracticeTask `json:"practice_tasks,omitempty"`
+	// Snippets — примеры ```go из Content, которые можно запустить; только для одного урока
+	Snippets []Snippet `json:"snippets,omitempty"`
+	// Rendered заполняется при ?format=html, Content тогда пустой
+	Rendered *RenderedContent `json:"rendered,omitempty"`
+}
+
+// Snippet — пример кода из урока
+type Snippet struct {
+	ID string `json:"id"`
+	// Index — номер среди блоков кода урока, совпадает с порядком RenderedContent.CodeBlocks
+	Index int    `json:"index"`
+	Code  string `json:"code"`
+	// Runnable: false, если автор пометил блок no-run
+	Runnable bool `json:"runnable"`
+}
+
+const (
+	PracticeTypeTask = "task"
+	PracticeTypeStep = "step"
+)
+
+// PracticeTask — задача или шаг проекта для закрепления урока.
+// Для шага ChapterSlug содержит slug проекта.
+type PracticeTask struct {
+	Type        string `json:"type"`
+	ChapterSlug string `json:"chapter_slug"`
+	Slug        string `json:"slug"`
+	Title       string `json:"title"`
+	Difficulty  string `json:"difficulty"`
+	// Solved: nil = не авторизован, true/false = авторизован
+	Solved *bool `json:"solved,omitempty"`
+}
+
+// LessonLink — ссылка задачи или шага проекта на урок теории
+type LessonLink struct {
+	ChapterSlug string `json:"chapter_slug"`
+	LessonSlug  string `json:"lesson_slug"`
+	Title       string `json:"title"`
 }
 
 type ChapterMeta struct {
@@ -34,7 +80,28 @@ type ChapterMeta struct {
 }
 
 type LessonFrontmatter struct {
-	Title       string `yaml:"title"`
-	Description string `yaml:"description"`
-	Order       int    `yaml:"order"`
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description"`
+	Order       int      `yaml:"order"`
+	Requires    []string `yaml:"requires"`
+}
+
+// RenderedContent — markdown, отрисованный на сервере (?format=html)
+type RenderedContent struct {
+	HTML string     `json:"html"`
+	TOC  []TOCEntry `json:"toc"`
+	// ReadingTime — оценка времени чтения в минутах
+	ReadingTime int         `json:"reading_time"`
+	CodeBlocks  []CodeBlock `json:"code_blocks"`
+}
+
+type TOCEntry struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type CodeBlock struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
 }
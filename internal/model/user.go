@@ -6,15 +6,105 @@ import (
 	"github.com/google/uuid"
 )
 
+type Role string
+
+const (
+	RoleStudent Role = "student"
+	RoleMentor  Role = "mentor"
+	RoleAdmin   Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleStudent, RoleMentor, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 type User struct {
 	ID           uuid.UUID  `json:"id"`
 	Email        string     `json:"email"`
 	Name         string     `json:"name"`
 	Picture      string     `json:"picture"`
-	GoogleID     string     `json:"google_id"`
+	Role         Role       `json:"role"`
 	TokenVersion int        `json:"-"`
 	IsActive     bool       `json:"is_active"`
 	LastLoginAt  *time.Time `json:"last_login_at"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
+	// DeleteAfter — когда аккаунт, удалённый пользователем, будет стёрт; до этого его можно восстановить
+	DeleteAfter *time.Time     `json:"delete_after,omitempty"`
+	Handle      string         `json:"handle,omitempty"`
+	Privacy     ProfilePrivacy `json:"privacy"`
+	// Locale — выбранный язык контента; пусто — по Accept-Language
+	Locale string `json:"locale"`
+}
+
+// ProfilePrivacy — что видно на публичной странице /api/u/{handle}; без Public страницы нет
+type ProfilePrivacy struct {
+	Public       bool `json:"public"`
+	ShowStats    bool `json:"show_stats"`
+	ShowChapters bool `json:"show_chapters"`
+	ShowProjects bool `json:"show_projects"`
+}
+
+// ProfileUpdate — изменения профиля; nil в Handle, Privacy и Locale оставляет их как есть,
+// пустой Handle убирает публичный адрес, пустой Locale возвращает выбор по Accept-Language
+type ProfileUpdate struct {
+	Name    string
+	Picture string
+	Handle  *string
+	Privacy *ProfilePrivacy
+	Locale  *string
+}
+
+// PublicProfile — публичная страница пользователя; разделы, скрытые настройками, не заполняются
+type PublicProfile struct {
+	Handle            string             `json:"handle"`
+	Name              string             `json:"name"`
+	Picture           string             `json:"picture"`
+	MemberSince       time.Time          `json:"member_since"`
+	Stats             *PublicStats       `json:"stats,omitempty"`
+	Chapters          []TaskChapterStats `json:"chapters,omitempty"`
+	CompletedProjects []PublicProject    `json:"completed_projects,omitempty"`
+}
+
+type PublicStats struct {
+	CompletedLessons  int `json:"completed_lessons"`
+	SolvedTasks       int `json:"solved_tasks"`
+	CompletedProjects int `json:"completed_projects"`
+}
+
+type PublicProject struct {
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+}
+
+// PendingDeletion — аккаунт удалён пользователем, но срок восстановления ещё не истёк
+func (u *User) PendingDeletion() bool {
+	return u.DeleteAfter != nil && time.Now().Before(*u.DeleteAfter)
+}
+
+// UserFilter — параметры поиска пользователей в админке; Query ищет по email и имени
+type UserFilter struct {
+	Query  string
+	Role   Role
+	Active *bool
+	Limit  int
+	Offset int
+}
+
+type UserList struct {
+	Users []User `json:"users"`
+	Total int    `json:"total"`
+}
+
+// UserAuthState — то, что проверяет middleware на каждом запросе; кешируется отдельно от профиля
+type UserAuthState struct {
+	TokenVersion int  `json:"token_version"`
+	IsActive     bool `json:"is_active"`
+	Role         Role `json:"role"`
+	// Locale — язык контента из профиля; middleware подставляет его в запрос
+	Locale string `json:"locale,omitempty"`
 }
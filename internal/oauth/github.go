@@ -0,0 +1,82 @@
+package oauth
+
+import (
+	"cmp"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/config"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+const gitHubAPIURL = "https://api.github.com"
+
+type gitHubProvider struct {
+	config *oauth2.Config
+	apiURL string
+}
+
+// NewGitHub создаёт провайдер GitHub. GITHUB_AUTH_URL, GITHUB_TOKEN_URL и GITHUB_API_URL
+// по умолчанию указывают на github.com; их можно направить на локальный фейковый сервер.
+func NewGitHub(cfg config.GitHubOAuthConfig) Provider {
+	return &gitHubProvider{
+		config: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.CallbackURL,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  cmp.Or(cfg.AuthURL, github.Endpoint.AuthURL),
+				TokenURL: cmp.Or(cfg.TokenURL, github.Endpoint.TokenURL),
+			},
+			Scopes: []string{"read:user", "user:email"},
+		},
+		apiURL: strings.TrimSuffix(cmp.Or(cfg.APIURL, gitHubAPIURL), "/"),
+	}
+}
+
+func (p *gitHubProvider) Name() string {
+	return "github"
+}
+
+func (p *gitHubProvider) AuthURL(state string) string {
+	return p.config.AuthCodeURL(state)
+}
+
+func (p *gitHubProvider) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	return p.config.Exchange(ctx, code)
+}
+
+// FetchProfile берёт основной подтверждённый email из /user/emails:
+// email в /user бывает пустым или неподтверждённым
+func (p *gitHubProvider) FetchProfile(ctx context.Context, token *oauth2.Token) (*model.OAuthProfile, error) {
+	client := p.config.Client(ctx, token)
+
+	var info model.GitHubUserInfo
+	if err := getJSON(ctx, client, p.apiURL+"/user", &info); err != nil {
+		return nil, err
+	}
+
+	var emails []model.GitHubEmail
+	if err := getJSON(ctx, client, p.apiURL+"/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	profile := &model.OAuthProfile{
+		Provider: p.Name(),
+		Subject:  strconv.FormatInt(info.ID, 10),
+		Name:     cmp.Or(info.Name, info.Login),
+		Picture:  info.AvatarURL,
+	}
+	for _, e := range emails {
+		if e.Primary {
+			profile.Email = e.Email
+			profile.EmailVerified = e.Verified
+			break
+		}
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,66 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/config"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+type googleProvider struct {
+	config      *oauth2.Config
+	userInfoURL string
+}
+
+func NewGoogle(cfg config.GoogleOAuthConfig) Provider {
+	return &googleProvider{
+		config: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.CallbackURL,
+			Endpoint:     google.Endpoint,
+			Scopes:       []string{"openid", "email", "profile"},
+		},
+		userInfoURL: cfg.UserInfoURL,
+	}
+}
+
+func (p *googleProvider) Name() string {
+	return "google"
+}
+
+func (p *googleProvider) AuthURL(state string) string {
+	return p.config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+}
+
+func (p *googleProvider) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	return p.config.Exchange(ctx, code)
+}
+
+func (p *googleProvider) FetchProfile(ctx context.Context, token *oauth2.Token) (*model.OAuthProfile, error) {
+	u, err := url.Parse(p.userInfoURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("access_token", token.AccessToken)
+	u.RawQuery = q.Encode()
+
+	var info model.GoogleUserInfo
+	if err := getJSON(ctx, http.DefaultClient, u.String(), &info); err != nil {
+		return nil, err
+	}
+
+	return &model.OAuthProfile{
+		Provider:      p.Name(),
+		Subject:       info.ID,
+		Email:         info.Email,
+		EmailVerified: info.VerifiedEmail,
+		Name:          info.Name,
+		Picture:       info.Picture,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"golang.org/x/oauth2"
+)
+
+// Provider — OAuth-провайдер входа: ссылка на согласие, обмен кода и профиль пользователя
+type Provider interface {
+	// Name — имя провайдера в URL и в user_identities, например "google"
+	Name() string
+	AuthURL(state string) string
+	Exchange(ctx context.Context, code string) (*oauth2.Token, error)
+	FetchProfile(ctx context.Context, token *oauth2.Token) (*model.OAuthProfile, error)
+}
+
+// getJSON выполняет GET к API провайдера и разбирает ответ в dst
+func getJSON(ctx context.Context, client *http.Client, url string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, url)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
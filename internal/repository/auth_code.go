@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// AuthCodeRepository хранит одноразовые коды входа, которые фронтенд меняет на токены
+type AuthCodeRepository interface {
+	Save(ctx context.Context, code string, data model.AuthCode, ttl time.Duration) error
+	// Take возвращает данные кода и удаляет его; nil — код неизвестен, истёк или уже использован
+	Take(ctx context.Context, code string) (*model.AuthCode, error)
+}
+
+type authCodeRepository struct {
+	client *redis.Client
+}
+
+func NewAuthCodeRepository(client *redis.Client) AuthCodeRepository {
+	return &authCodeRepository{client: client}
+}
+
+func (r *authCodeRepository) Save(ctx context.Context, code string, data model.AuthCode, ttl time.Duration) error {
+	key := fmt.Sprintf("auth_code:%s", code)
+	value, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (r *authCodeRepository) Take(ctx context.Context, code string) (*model.AuthCode, error) {
+	key := fmt.Sprintf("auth_code:%s", code)
+
+	result, err := r.client.GetDel(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data model.AuthCode
+	if err := json.Unmarshal(result, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// AuthStateCache хранит в Redis token version, роль и статус пользователя,
+// чтобы middleware не ходил в Postgres на каждый запрос
+type AuthStateCache interface {
+	// Get возвращает nil, если записи нет
+	Get(ctx context.Context, userID uuid.UUID) (*model.UserAuthState, error)
+	Set(ctx context.Context, userID uuid.UUID, state *model.UserAuthState, ttl time.Duration) error
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
+
+type authStateCache struct {
+	client *redis.Client
+}
+
+func NewAuthStateCache(client *redis.Client) AuthStateCache {
+	return &authStateCache{client: client}
+}
+
+func authStateKey(userID uuid.UUID) string { return fmt.Sprintf("auth_state:%s", userID) }
+
+func (c *authStateCache) Get(ctx context.Context, userID uuid.UUID) (*model.UserAuthState, error) {
+	data, err := c.client.Get(ctx, authStateKey(userID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state model.UserAuthState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (c *authStateCache) Set(ctx context.Context, userID uuid.UUID, state *model.UserAuthState, ttl time.Duration) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, authStateKey(userID), data, ttl).Err()
+}
+
+func (c *authStateCache) Delete(ctx context.Context, userID uuid.UUID) error {
+	return c.client.Del(ctx, authStateKey(userID)).Err()
+}
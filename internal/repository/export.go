@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var ExportNotFound = errors.New("export not found")
+
+// ExportRepository хранит в Redis состояние выгрузки пользователя и готовые архивы;
+// архив доступен по случайному токену до истечения ttl
+type ExportRepository interface {
+	// Lock не даёт запустить вторую сборку, пока идёт первая; false — блокировка занята
+	Lock(ctx context.Context, userID uuid.UUID, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, userID uuid.UUID) error
+	SaveJob(ctx context.Context, userID uuid.UUID, job *model.ExportJob, ttl time.Duration) error
+	GetJob(ctx context.Context, userID uuid.UUID) (*model.ExportJob, error)
+	// SaveArchive сохраняет архив пользователя; прежний архив удаляется
+	SaveArchive(ctx context.Context, userID uuid.UUID, token string, data []byte, ttl time.Duration) error
+	GetArchive(ctx context.Context, token string) ([]byte, error)
+	// Delete удаляет задачу и архив пользователя, чтобы данные удалённого аккаунта нельзя было скачать
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
+
+type exportRepository struct {
+	client *redis.Client
+}
+
+func NewExportRepository(client *redis.Client) ExportRepository {
+	return &exportRepository{client: client}
+}
+
+func exportJobKey(userID uuid.UUID) string  { return fmt.Sprintf("export_job:%s", userID) }
+func exportLockKey(userID uuid.UUID) string { return fmt.Sprintf("export_lock:%s", userID) }
+func exportArchiveKey(token string) string  { return fmt.Sprintf("export_archive:%s", token) }
+
+// exportTokenKey хранит токен текущего архива пользователя, чтобы архив можно было удалить
+func exportTokenKey(userID uuid.UUID) string { return fmt.Sprintf("export_token:%s", userID) }
+
+func (r *exportRepository) Lock(ctx context.Context, userID uuid.UUID, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, exportLockKey(userID), 1, ttl).Result()
+}
+
+func (r *exportRepository) Unlock(ctx context.Context, userID uuid.UUID) error {
+	return r.client.Del(ctx, exportLockKey(userID)).Err()
+}
+
+func (r *exportRepository) SaveJob(ctx context.Context, userID uuid.UUID, job *model.ExportJob, ttl time.Duration) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, exportJobKey(userID), data, ttl).Err()
+}
+
+func (r *exportRepository) GetJob(ctx context.Context, userID uuid.UUID) (*model.ExportJob, error) {
+	data, err := r.client.Get(ctx, exportJobKey(userID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ExportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job model.ExportJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *exportRepository) SaveArchive(ctx context.Context, userID uuid.UUID, token string, data []byte, ttl time.Duration) error {
+	previous, err := r.client.Get(ctx, exportTokenKey(userID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	if previous != "" {
+		pipe.Del(ctx, exportArchiveKey(previous))
+	}
+	pipe.Set(ctx, exportArchiveKey(token), data, ttl)
+	pipe.Set(ctx, exportTokenKey(userID), token, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *exportRepository) GetArchive(ctx context.Context, token string) ([]byte, error) {
+	data, err := r.client.Get(ctx, exportArchiveKey(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ExportNotFound
+	}
+	return data, err
+}
+
+func (r *exportRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	token, err := r.client.Get(ctx, exportTokenKey(userID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	keys := []string{exportJobKey(userID), exportTokenKey(userID)}
+	if token != "" {
+		keys = append(keys, exportArchiveKey(token))
+	}
+	return r.client.Del(ctx, keys...).Err()
+}
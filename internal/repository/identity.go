@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	IdentityNotFound = errors.New("identity not found")
+	IdentityExists   = errors.New("identity already exists")
+)
+
+type IdentityRepository interface {
+	// Get ищет привязку по провайдеру и id пользователя у него
+	Get(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]model.UserIdentity, error)
+	// Add возвращает IdentityExists, если аккаунт провайдера уже привязан
+	// или у пользователя уже есть привязка этого провайдера
+	Add(ctx context.Context, identity *model.UserIdentity) error
+	Delete(ctx context.Context, userID uuid.UUID, provider string) error
+}
+
+type identityRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdentityRepository(db *pgxpool.Pool) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) Get(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	query := `
+	SELECT provider, subject, user_id, email, created_at
+	FROM user_identities
+	WHERE provider = $1 AND subject = $2
+	`
+
+	i := &model.UserIdentity{}
+	err := r.db.QueryRow(ctx, query, provider, subject).Scan(&i.Provider, &i.Subject, &i.UserID, &i.Email, &i.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, IdentityNotFound
+		}
+		return nil, err
+	}
+
+	return i, nil
+}
+
+func (r *identityRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.UserIdentity, error) {
+	query := `
+	SELECT provider, subject, user_id, email, created_at
+	FROM user_identities
+	WHERE user_id = $1
+	ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []model.UserIdentity{}
+	for rows.Next() {
+		var i model.UserIdentity
+		if err := rows.Scan(&i.Provider, &i.Subject, &i.UserID, &i.Email, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, i)
+	}
+
+	return identities, rows.Err()
+}
+
+func (r *identityRepository) Add(ctx context.Context, i *model.UserIdentity) error {
+	query := `
+	INSERT INTO user_identities (provider, subject, user_id, email)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT DO NOTHING
+	RETURNING created_at
+	`
+
+	err := r.db.QueryRow(ctx, query, i.Provider, i.Subject, i.UserID, i.Email).Scan(&i.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return IdentityExists
+	}
+	return err
+}
+
+func (r *identityRepository) Delete(ctx context.Context, userID uuid.UUID, provider string) error {
+	query := `
+	DELETE FROM user_identities
+	WHERE user_id = $1 AND provider = $2
+	`
+
+	tag, err := r.db.Exec(ctx, query, userID, provider)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return IdentityNotFound
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// LinkCodeRepository хранит одноразовые коды привязки провайдера до подтверждения пользователем
+type LinkCodeRepository interface {
+	Save(ctx context.Context, code string, data model.PendingLink, ttl time.Duration) error
+	// Take возвращает данные кода и удаляет его; nil — код неизвестен, истёк или уже использован
+	Take(ctx context.Context, code string) (*model.PendingLink, error)
+}
+
+type linkCodeRepository struct {
+	client *redis.Client
+}
+
+func NewLinkCodeRepository(client *redis.Client) LinkCodeRepository {
+	return &linkCodeRepository{client: client}
+}
+
+func linkCodeKey(code string) string { return fmt.Sprintf("link_code:%s", code) }
+
+func (r *linkCodeRepository) Save(ctx context.Context, code string, data model.PendingLink, ttl time.Duration) error {
+	value, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, linkCodeKey(code), value, ttl).Err()
+}
+
+func (r *linkCodeRepository) Take(ctx context.Context, code string) (*model.PendingLink, error) {
+	result, err := r.client.GetDel(ctx, linkCodeKey(code)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var data model.PendingLink
+	if err := json.Unmarshal(result, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
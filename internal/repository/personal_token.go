@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	PersonalTokenNotFound = errors.New("personal token not found")
+)
+
+type PersonalTokenRepository interface {
+	Create(ctx context.Context, t *model.PersonalToken) error
+	// GetByHash ищет токен по SHA-256 значения, включая истёкшие
+	GetByHash(ctx context.Context, hash string) (*model.PersonalToken, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]model.PersonalToken, error)
+	CountByUser(ctx context.Context, userID uuid.UUID) (int, error)
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+	// Touch обновляет last_used_at не чаще раза в interval, чтобы не писать в базу на каждый запрос
+	Touch(ctx context.Context, id uuid.UUID, interval time.Duration) error
+}
+
+type personalTokenRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPersonalTokenRepository(db *pgxpool.Pool) PersonalTokenRepository {
+	return &personalTokenRepository{db: db}
+}
+
+func (r *personalTokenRepository) Create(ctx context.Context, t *model.PersonalToken) error {
+	query := `
+	INSERT INTO personal_access_tokens (user_id, name, token_hash, prefix, scopes, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, created_at
+	`
+
+	return r.db.QueryRow(ctx, query, t.UserID, t.Name, t.Hash, t.Prefix, t.Scopes, t.ExpiresAt).
+		Scan(&t.ID, &t.CreatedAt)
+}
+
+func (r *personalTokenRepository) GetByHash(ctx context.Context, hash string) (*model.PersonalToken, error) {
+	query := `
+	SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at
+	FROM personal_access_tokens
+	WHERE token_hash = $1
+	`
+
+	t := &model.PersonalToken{}
+	err := r.db.QueryRow(ctx, query, hash).Scan(
+		&t.ID, &t.UserID, &t.Name, &t.Prefix, &t.Scopes, &t.ExpiresAt, &t.LastUsedAt, &t.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, PersonalTokenNotFound
+		}
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (r *personalTokenRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.PersonalToken, error) {
+	query := `
+	SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at
+	FROM personal_access_tokens
+	WHERE user_id = $1
+	ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []model.PersonalToken{}
+	for rows.Next() {
+		var t model.PersonalToken
+		err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &t.Scopes, &t.ExpiresAt, &t.LastUsedAt, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (r *personalTokenRepository) CountByUser(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `SELECT count(*) FROM personal_access_tokens WHERE user_id = $1`
+
+	var count int
+	err := r.db.QueryRow(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+func (r *personalTokenRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	query := `
+	DELETE FROM personal_access_tokens
+	WHERE id = $1 AND user_id = $2
+	`
+
+	tag, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return PersonalTokenNotFound
+	}
+	return nil
+}
+
+func (r *personalTokenRepository) Touch(ctx context.Context, id uuid.UUID, interval time.Duration) error {
+	query := `
+	UPDATE personal_access_tokens
+	SET last_used_at = $2
+	WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $3)
+	`
+
+	now := time.Now()
+	_, err := r.db.Exec(ctx, query, id, now, now.Add(-interval))
+	return err
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	QuizAttemptNotFound = errors.New("quiz attempt not found")
+	QuizAnswerExists    = errors.New("quiz answer already exists")
+)
+
+type QuizAttemptRepository interface {
+	Create(ctx context.Context, a *model.QuizAttempt) error
+	// GetByID возвращает попытку вместе с ответами
+	GetByID(ctx context.Context, id uuid.UUID) (*model.QuizAttempt, error)
+	SaveAnswer(ctx context.Context, attemptID, userID uuid.UUID, answer model.QuizAttemptAnswer) error
+	Finish(ctx context.Context, a *model.QuizAttempt) error
+	// ListFinished — завершённые попытки пользователя с ответами, от новых к старым
+	ListFinished(ctx context.Context, userID uuid.UUID) ([]model.QuizAttempt, error)
+	// GetQuestionHistory: question id -> верен ли последний ответ на вопрос
+	GetQuestionHistory(ctx context.Context, userID uuid.UUID) (map[string]bool, error)
+}
+
+type quizAttemptRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewQuizAttemptRepository(db *pgxpool.Pool) QuizAttemptRepository {
+	return &quizAttemptRepository{db: db}
+}
+
+func (r *quizAttemptRepository) Create(ctx context.Context, a *model.QuizAttempt) error {
+	query := `
+	INSERT INTO quiz_attempts (user_id, question_ids)
+	VALUES ($1, $2)
+	RETURNING id, started_at
+	`
+
+	return r.db.QueryRow(ctx, query, a.UserID, a.QuestionIDs).Scan(&a.ID, &a.StartedAt)
+}
+
+func (r *quizAttemptRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.QuizAttempt, error) {
+	query := `
+	SELECT id, user_id, question_ids, score, correct, started_at, finished_at
+	FROM quiz_attempts
+	WHERE id = $1
+	`
+
+	a := &model.QuizAttempt{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&a.ID, &a.UserID, &a.QuestionIDs, &a.Score, &a.Correct, &a.StartedAt, &a.FinishedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, QuizAttemptNotFound
+		}
+		return nil, err
+	}
+
+	answers, err := r.listAnswers(ctx, []uuid.UUID{a.ID})
+	if err != nil {
+		return nil, err
+	}
+	a.Answers = answers[a.ID]
+	a.Answered = len(a.Answers)
+	a.Total = len(a.QuestionIDs)
+
+	return a, nil
+}
+
+func (r *quizAttemptRepository) SaveAnswer(ctx context.Context, attemptID, userID uuid.UUID, answer model.QuizAttemptAnswer) error {
+	answerJSON, err := json.Marshal(answer.Answer)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO quiz_answers (attempt_id, user_id, question_id, answer, correct, score)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (attempt_id, question_id) DO NOTHING
+	`
+
+	tag, err := r.db.Exec(ctx, query, attemptID, userID, answer.QuestionID, answerJSON, answer.Correct, answer.Score)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return QuizAnswerExists
+	}
+	return nil
+}
+
+func (r *quizAttemptRepository) Finish(ctx context.Context, a *model.QuizAttempt) error {
+	query := `
+	UPDATE quiz_attempts
+	SET score = $2, correct = $3, finished_at = now()
+	WHERE id = $1 AND finished_at IS NULL
+	RETURNING finished_at
+	`
+
+	var finishedAt time.Time
+	err := r.db.QueryRow(ctx, query, a.ID, a.Score, a.Correct).Scan(&finishedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return QuizAttemptNotFound
+		}
+		return err
+	}
+	a.FinishedAt = &finishedAt
+	return nil
+}
+
+func (r *quizAttemptRepository) ListFinished(ctx context.Context, userID uuid.UUID) ([]model.QuizAttempt, error) {
+	query := `
+	SELECT id, user_id, question_ids, score, correct, started_at, finished_at
+	FROM quiz_attempts
+	WHERE user_id = $1 AND finished_at IS NOT NULL
+	ORDER BY finished_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []model.QuizAttempt
+	var ids []uuid.UUID
+	for rows.Next() {
+		var a model.QuizAttempt
+		if err := rows.Scan(&a.ID, &a.UserID, &a.QuestionIDs, &a.Score, &a.Correct, &a.StartedAt, &a.FinishedAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+		ids = append(ids, a.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	answers, err := r.listAnswers(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range attempts {
+		attempts[i].Answers = answers[attempts[i].ID]
+		attempts[i].Answered = len(attempts[i].Answers)
+		attempts[i].Total = len(attempts[i].QuestionIDs)
+	}
+
+	return attempts, nil
+}
+
+func (r *quizAttemptRepository) GetQuestionHistory(ctx context.Context, userID uuid.UUID) (map[string]bool, error) {
+	query := `
+	SELECT DISTINCT ON (question_id) question_id, correct
+	FROM quiz_answers
+	WHERE user_id = $1
+	ORDER BY question_id, answered_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make(map[string]bool)
+	for rows.Next() {
+		var questionID string
+		var correct bool
+		if err := rows.Scan(&questionID, &correct); err != nil {
+			return nil, err
+		}
+		history[questionID] = correct
+	}
+
+	return history, rows.Err()
+}
+
+func (r *quizAttemptRepository) listAnswers(ctx context.Context, attemptIDs []uuid.UUID) (map[uuid.UUID][]model.QuizAttemptAnswer, error) {
+	query := `
+	SELECT attempt_id, question_id, answer, correct, score
+	FROM quiz_answers
+	WHERE attempt_id = ANY($1::uuid[])
+	ORDER BY answered_at
+	`
+
+	ids := make([]string, len(attemptIDs))
+	for i, id := range attemptIDs {
+		ids[i] = id.String()
+	}
+
+	rows, err := r.db.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	answers := make(map[uuid.UUID][]model.QuizAttemptAnswer)
+	for rows.Next() {
+		var attemptID uuid.UUID
+		var a model.QuizAttemptAnswer
+		var answerJSON []byte
+		if err := rows.Scan(&attemptID, &a.QuestionID, &answerJSON, &a.Correct, &a.Score); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(answerJSON, &a.Answer); err != nil {
+			return nil, err
+		}
+		answers[attemptID] = append(answers[attemptID], a)
+	}
+
+	return answers, rows.Err()
+}
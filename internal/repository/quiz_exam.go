@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	QuizExamNotFound  = errors.New("quiz exam not found")
+	QuizExamSubmitted = errors.New("quiz exam already submitted")
+)
+
+// QuizExamRepository хранит экзамены в Redis: сессия, ответы (hash) и отчёт живут до ttl
+type QuizExamRepository interface {
+	Create(ctx context.Context, session *model.QuizExamSession, ttl time.Duration) error
+	Get(ctx context.Context, id string) (*model.QuizExamSession, error)
+	SaveAnswer(ctx context.Context, id, questionID string, answer []string) error
+	GetAnswers(ctx context.Context, id string) (map[string][]string, error)
+	// SaveReport записывает отчёт один раз и снимает пометку активного экзамена;
+	// повторная сдача возвращает QuizExamSubmitted
+	SaveReport(ctx context.Context, s *model.QuizExamSession, report *model.QuizExamReport) error
+	GetReport(ctx context.Context, id string) (*model.QuizExamReport, error)
+	// ActiveSession — id последнего несданного экзамена пользователя или "", если его нет.
+	// Пометка живёт вместе с сессией; идёт ли экзамен, решает сервис по времени сессии
+	ActiveSession(ctx context.Context, userID uuid.UUID) (string, error)
+}
+
+type quizExamRepository struct {
+	client *redis.Client
+}
+
+func NewQuizExamRepository(client *redis.Client) QuizExamRepository {
+	return &quizExamRepository{client: client}
+}
+
+func examKey(id string) string              { return fmt.Sprintf("quiz_exam:%s", id) }
+func examAnswersKey(id string) string       { return fmt.Sprintf("quiz_exam:%s:answers", id) }
+func examReportKey(id string) string        { return fmt.Sprintf("quiz_exam:%s:report", id) }
+func examActiveKey(userID uuid.UUID) string { return fmt.Sprintf("quiz_exam_active:%s", userID) }
+
+func (r *quizExamRepository) Create(ctx context.Context, s *model.QuizExamSession, ttl time.Duration) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, examKey(s.ID), data, ttl)
+	pipe.Set(ctx, examActiveKey(s.UserID), s.ID, ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *quizExamRepository) Get(ctx context.Context, id string) (*model.QuizExamSession, error) {
+	data, err := r.client.Get(ctx, examKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, QuizExamNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s model.QuizExamSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *quizExamRepository) SaveAnswer(ctx context.Context, id, questionID string, answer []string) error {
+	data, err := json.Marshal(answer)
+	if err != nil {
+		return err
+	}
+
+	ttl, err := r.client.TTL(ctx, examKey(id)).Result()
+	if err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return QuizExamNotFound
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.HSet(ctx, examAnswersKey(id), questionID, data)
+	pipe.Expire(ctx, examAnswersKey(id), ttl)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *quizExamRepository) GetAnswers(ctx context.Context, id string) (map[string][]string, error) {
+	raw, err := r.client.HGetAll(ctx, examAnswersKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	answers := make(map[string][]string, len(raw))
+	for questionID, data := range raw {
+		var answer []string
+		if err := json.Unmarshal([]byte(data), &answer); err != nil {
+			return nil, err
+		}
+		answers[questionID] = answer
+	}
+	return answers, nil
+}
+
+func (r *quizExamRepository) SaveReport(ctx context.Context, s *model.QuizExamSession, report *model.QuizExamReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	ttl, err := r.client.TTL(ctx, examKey(s.ID)).Result()
+	if err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return QuizExamNotFound
+	}
+
+	ok, err := r.client.SetNX(ctx, examReportKey(s.ID), data, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return QuizExamSubmitted
+	}
+
+	active, err := r.ActiveSession(ctx, s.UserID)
+	if err != nil || active != s.ID {
+		return err
+	}
+	return r.client.Del(ctx, examActiveKey(s.UserID)).Err()
+}
+
+func (r *quizExamRepository) GetReport(ctx context.Context, id string) (*model.QuizExamReport, error) {
+	data, err := r.client.Get(ctx, examReportKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var report model.QuizExamReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *quizExamRepository) ActiveSession(ctx context.Context, userID uuid.UUID) (string, error) {
+	id, err := r.client.Get(ctx, examActiveKey(userID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return id, err
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	QuizReviewNotFound = errors.New("quiz review card not found")
+)
+
+type QuizReviewRepository interface {
+	Get(ctx context.Context, userID uuid.UUID, questionID string) (*model.QuizReviewCard, error)
+	Save(ctx context.Context, userID uuid.UUID, card *model.QuizReviewCard) error
+	// List возвращает все карточки пользователя по возрастанию due_at
+	List(ctx context.Context, userID uuid.UUID) ([]model.QuizReviewCard, error)
+}
+
+type quizReviewRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewQuizReviewRepository(db *pgxpool.Pool) QuizReviewRepository {
+	return &quizReviewRepository{db: db}
+}
+
+func (r *quizReviewRepository) Get(ctx context.Context, userID uuid.UUID, questionID string) (*model.QuizReviewCard, error) {
+	query := `
+	SELECT question_id, revision, ease, interval_days, repetitions, due_at, reviewed_at
+	FROM quiz_reviews
+	WHERE user_id = $1 AND question_id = $2
+	`
+
+	c := &model.QuizReviewCard{}
+	err := r.db.QueryRow(ctx, query, userID, questionID).Scan(
+		&c.QuestionID, &c.Revision, &c.Ease, &c.IntervalDays, &c.Repetitions, &c.DueAt, &c.ReviewedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, QuizReviewNotFound
+		}
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (r *quizReviewRepository) Save(ctx context.Context, userID uuid.UUID, c *model.QuizReviewCard) error {
+	query := `
+	INSERT INTO quiz_reviews (user_id, question_id, revision, ease, interval_days, repetitions, due_at, reviewed_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (user_id, question_id) DO UPDATE
+	SET revision = EXCLUDED.revision,
+		ease = EXCLUDED.ease,
+		interval_days = EXCLUDED.interval_days,
+		repetitions = EXCLUDED.repetitions,
+		due_at = EXCLUDED.due_at,
+		reviewed_at = EXCLUDED.reviewed_at
+	`
+
+	_, err := r.db.Exec(ctx, query, userID, c.QuestionID, c.Revision, c.Ease, c.IntervalDays, c.Repetitions, c.DueAt, c.ReviewedAt)
+	return err
+}
+
+func (r *quizReviewRepository) List(ctx context.Context, userID uuid.UUID) ([]model.QuizReviewCard, error) {
+	query := `
+	SELECT question_id, revision, ease, interval_days, repetitions, due_at, reviewed_at
+	FROM quiz_reviews
+	WHERE user_id = $1
+	ORDER BY due_at
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []model.QuizReviewCard
+	for rows.Next() {
+		var c model.QuizReviewCard
+		if err := rows.Scan(&c.QuestionID, &c.Revision, &c.Ease, &c.IntervalDays, &c.Repetitions, &c.DueAt, &c.ReviewedAt); err != nil {
+			return nil, err
+		}
+		cards = append(cards, c)
+	}
+
+	return cards, rows.Err()
+}
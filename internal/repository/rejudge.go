@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RejudgeRepository interface {
+	// Apply обновляет результат посылки и записывает перепроверку в одной транзакции
+	Apply(ctx context.Context, r *model.Rejudge) error
+	// ListByUser — перепроверки посылок пользователя, по посылке и времени
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]model.Rejudge, error)
+	// TryLock захватывает общую для всех процессов блокировку перепроверки (advisory lock
+	// Postgres); ok=false — она занята. unlock снимает блокировку; при падении процесса
+	// Postgres снимает её сам вместе с соединением
+	TryLock(ctx context.Context) (unlock func(), ok bool, err error)
+}
+
+// rejudgeLockID — ключ advisory lock перепроверки
+const rejudgeLockID int64 = 0x72656a75646765
+
+type rejudgeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRejudgeRepository(db *pgxpool.Pool) RejudgeRepository {
+	return &rejudgeRepository{db: db}
+}
+
+func (r *rejudgeRepository) Apply(ctx context.Context, rj *model.Rejudge) error {
+	oldResult, err := json.Marshal(rj.OldResult)
+	if err != nil {
+		return err
+	}
+	newResult, err := json.Marshal(rj.NewResult)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	updateQuery := `
+	UPDATE submissions
+	SET passed = $2, result = $3
+	WHERE id = $1
+	`
+	tag, err := tx.Exec(ctx, updateQuery, rj.SubmissionID, rj.NewPassed, newResult)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return SubmissionNotFound
+	}
+
+	insertQuery := `
+	INSERT INTO submission_rejudges (submission_id, reason, old_passed, new_passed, old_result, new_result)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, insertQuery, rj.SubmissionID, rj.Reason, rj.OldPassed, rj.NewPassed, oldResult, newResult).
+		Scan(&rj.ID, &rj.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *rejudgeRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.Rejudge, error) {
+	query := `
+	SELECT rj.id, rj.submission_id, rj.reason, rj.old_passed, rj.new_passed, rj.old_result, rj.new_result, rj.created_at
+	FROM submission_rejudges rj
+	JOIN submissions s ON s.id = rj.submission_id
+	WHERE s.user_id = $1
+	ORDER BY rj.submission_id, rj.created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rejudges := []model.Rejudge{}
+	for rows.Next() {
+		var rj model.Rejudge
+		var oldResult, newResult []byte
+		err := rows.Scan(&rj.ID, &rj.SubmissionID, &rj.Reason, &rj.OldPassed, &rj.NewPassed, &oldResult, &newResult, &rj.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if oldResult != nil {
+			if err := json.Unmarshal(oldResult, &rj.OldResult); err != nil {
+				return nil, err
+			}
+		}
+		if newResult != nil {
+			if err := json.Unmarshal(newResult, &rj.NewResult); err != nil {
+				return nil, err
+			}
+		}
+		rejudges = append(rejudges, rj)
+	}
+
+	return rejudges, rows.Err()
+}
+
+func (r *rejudgeRepository) TryLock(ctx context.Context) (func(), bool, error) {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, rejudgeLockID).Scan(&ok); err != nil {
+		conn.Release()
+		return nil, false, err
+	}
+	if !ok {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		ctx := context.Background()
+		if _, err := conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, rejudgeLockID); err != nil {
+			// закрытое соединение снимает блокировку вместе с сессией
+			conn.Conn().Close(ctx)
+		}
+		conn.Release()
+	}
+	return unlock, true, nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RevokedSessionRepository хранит отозванные сессии, пока их access token ещё не истекли
+type RevokedSessionRepository interface {
+	Add(ctx context.Context, sessionID uuid.UUID, ttl time.Duration) error
+	Exists(ctx context.Context, sessionID uuid.UUID) (bool, error)
+}
+
+type revokedSessionRepository struct {
+	client *redis.Client
+}
+
+func NewRevokedSessionRepository(client *redis.Client) RevokedSessionRepository {
+	return &revokedSessionRepository{client: client}
+}
+
+func revokedSessionKey(sessionID uuid.UUID) string {
+	return fmt.Sprintf("revoked_session:%s", sessionID)
+}
+
+func (r *revokedSessionRepository) Add(ctx context.Context, sessionID uuid.UUID, ttl time.Duration) error {
+	return r.client.Set(ctx, revokedSessionKey(sessionID), 1, ttl).Err()
+}
+
+func (r *revokedSessionRepository) Exists(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	n, err := r.client.Exists(ctx, revokedSessionKey(sessionID)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	SessionNotFound = errors.New("session not found")
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, s *model.Session) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Session, error)
+	// Rotate заменяет refresh jti, только если текущий равен oldJTI и сессия не отозвана;
+	// иначе возвращает SessionNotFound
+	Rotate(ctx context.Context, s *model.Session, oldJTI uuid.UUID) error
+	// ListActive — неотозванные и неистёкшие сессии пользователя, последние активные первыми
+	ListActive(ctx context.Context, userID uuid.UUID) ([]model.Session, error)
+	Revoke(ctx context.Context, userID, id uuid.UUID) error
+	RevokeAll(ctx context.Context, userID uuid.UUID) error
+}
+
+type sessionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, s *model.Session) error {
+	query := `
+	INSERT INTO sessions (user_id, refresh_jti, device, ip, user_agent, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, created_at, last_seen_at
+	`
+
+	return r.db.QueryRow(ctx, query, s.UserID, s.RefreshJTI, s.Device, s.IP, s.UserAgent, s.ExpiresAt).
+		Scan(&s.ID, &s.CreatedAt, &s.LastSeenAt)
+}
+
+func (r *sessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.Session, error) {
+	query := `
+	SELECT id, user_id, refresh_jti, device, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at
+	FROM sessions
+	WHERE id = $1
+	`
+
+	s := &model.Session{}
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&s.ID, &s.UserID, &s.RefreshJTI, &s.Device, &s.IP, &s.UserAgent,
+		&s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, SessionNotFound
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (r *sessionRepository) Rotate(ctx context.Context, s *model.Session, oldJTI uuid.UUID) error {
+	query := `
+	UPDATE sessions
+	SET refresh_jti = $3, device = $4, ip = $5, user_agent = $6, last_seen_at = now(), expires_at = $7
+	WHERE id = $1 AND refresh_jti = $2 AND revoked_at IS NULL
+	RETURNING last_seen_at
+	`
+
+	err := r.db.QueryRow(ctx, query, s.ID, oldJTI, s.RefreshJTI, s.Device, s.IP, s.UserAgent, s.ExpiresAt).
+		Scan(&s.LastSeenAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return SessionNotFound
+	}
+	return err
+}
+
+func (r *sessionRepository) ListActive(ctx context.Context, userID uuid.UUID) ([]model.Session, error) {
+	query := `
+	SELECT id, user_id, refresh_jti, device, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at
+	FROM sessions
+	WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+	ORDER BY last_seen_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []model.Session{}
+	for rows.Next() {
+		var s model.Session
+		err := rows.Scan(
+			&s.ID, &s.UserID, &s.RefreshJTI, &s.Device, &s.IP, &s.UserAgent,
+			&s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, userID, id uuid.UUID) error {
+	query := `
+	UPDATE sessions
+	SET revoked_at = now()
+	WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+
+	tag, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return SessionNotFound
+	}
+	return nil
+}
+
+func (r *sessionRepository) RevokeAll(ctx context.Context, userID uuid.UUID) error {
+	query := `
+	UPDATE sessions
+	SET revoked_at = now()
+	WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := r.db.Exec(ctx, query, userID)
+	return err
+}
@@ -2,16 +2,19 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
 	"github.com/redis/go-redis/v9"
 )
 
 type StateRepository interface {
-	Save(ctx context.Context, state string, ttl time.Duration) error
-	Validate(ctx context.Context, state string) (bool, error)
+	Save(ctx context.Context, state string, data model.OAuthState, ttl time.Duration) error
+	// Take возвращает данные state и удаляет его; nil — state неизвестен или истёк
+	Take(ctx context.Context, state string) (*model.OAuthState, error)
 }
 
 type stateRepository struct {
@@ -22,21 +25,29 @@ func NewStateRepository(client *redis.Client) StateRepository {
 	return &stateRepository{client: client}
 }
 
-func (r *stateRepository) Save(ctx context.Context, state string, ttl time.Duration) error {
+func (r *stateRepository) Save(ctx context.Context, state string, data model.OAuthState, ttl time.Duration) error {
 	key := fmt.Sprintf("oauth_state:%s", state)
-	return r.client.Set(ctx, key, "1", ttl).Err()
+	value, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, key, value, ttl).Err()
 }
 
-func (r *stateRepository) Validate(ctx context.Context, state string) (bool, error) {
+func (r *stateRepository) Take(ctx context.Context, state string) (*model.OAuthState, error) {
 	key := fmt.Sprintf("oauth_state:%s", state)
 
-	result, err := r.client.GetDel(ctx, key).Result()
+	result, err := r.client.GetDel(ctx, key).Bytes()
 	if errors.Is(err, redis.Nil) {
-		return false, nil
+		return nil, nil
 	}
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	return result == "1", nil
+	var data model.OAuthState
+	if err := json.Unmarshal(result, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
 }
@@ -19,8 +19,11 @@ type SubmissionRepository interface {
 	Create(ctx context.Context, s *model.Submission) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Submission, error)
 	ListByUserAndTask(ctx context.Context, userID uuid.UUID, chapterSlug, taskSlug string) ([]model.Submission, error)
+	ListByTask(ctx context.Context, chapterSlug, taskSlug string) ([]model.Submission, error)
 	GetSolvedTasks(ctx context.Context, userID uuid.UUID) ([]model.SolvedTask, error)
 	HasSolved(ctx context.Context, userID uuid.UUID, chapterSlug, taskSlug string) (bool, error)
+	// ListByUser — все посылки пользователя по главам и задачам, старые первыми
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]model.Submission, error)
 }
 
 type submissionRepository struct {
@@ -111,6 +114,43 @@ func (r *submissionRepository) ListByUserAndTask(ctx context.Context, userID uui
 	return submissions, rows.Err()
 }
 
+// ListByTask возвращает посылки всех пользователей по задаче, сгруппированные по user_id, новые первыми
+func (r *submissionRepository) ListByTask(ctx context.Context, chapterSlug, taskSlug string) ([]model.Submission, error) {
+	query := `
+	SELECT id, user_id, chapter_slug, task_slug, code, passed, result, created_at
+	FROM submissions
+	WHERE chapter_slug = $1 AND task_slug = $2
+	ORDER BY user_id, created_at DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, chapterSlug, taskSlug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var submissions []model.Submission
+	for rows.Next() {
+		var s model.Submission
+		var resultJSON []byte
+
+		if err := rows.Scan(
+			&s.ID, &s.UserID, &s.ChapterSlug, &s.TaskSlug,
+			&s.Code, &s.Passed, &resultJSON, &s.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(resultJSON, &s.Result); err != nil {
+			return nil, err
+		}
+
+		submissions = append(submissions, s)
+	}
+
+	return submissions, rows.Err()
+}
+
 func (r *submissionRepository) GetSolvedTasks(ctx context.Context, userID uuid.UUID) ([]model.SolvedTask, error) {
 	query := `
 	SELECT DISTINCT chapter_slug, task_slug
@@ -149,3 +189,39 @@ func (r *submissionRepository) HasSolved(ctx context.Context, userID uuid.UUID,
 	err := r.db.QueryRow(ctx, query, userID, chapterSlug, taskSlug).Scan(&exists)
 	return exists, err
 }
+
+func (r *submissionRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.Submission, error) {
+	query := `
+	SELECT id, user_id, chapter_slug, task_slug, code, passed, result, created_at
+	FROM submissions
+	WHERE user_id = $1
+	ORDER BY chapter_slug, task_slug, created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	submissions := []model.Submission{}
+	for rows.Next() {
+		var s model.Submission
+		var resultJSON []byte
+
+		if err := rows.Scan(
+			&s.ID, &s.UserID, &s.ChapterSlug, &s.TaskSlug,
+			&s.Code, &s.Passed, &resultJSON, &s.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(resultJSON, &s.Result); err != nil {
+			return nil, err
+		}
+
+		submissions = append(submissions, s)
+	}
+
+	return submissions, rows.Err()
+}
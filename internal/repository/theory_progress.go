@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -11,6 +12,7 @@ type TheoryProgressRepository interface {
 	MarkCompleted(ctx context.Context, userID uuid.UUID, chapterSlug, lessonSlug string) error
 	GetCompletedTheories(ctx context.Context, userID uuid.UUID) (map[string]map[string]bool, error)
 	IsCompleted(ctx context.Context, userID uuid.UUID, chapterSlug, lessonSlug string) (bool, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]model.LessonProgress, error)
 }
 
 type theoryProgressRepository struct {
@@ -74,3 +76,29 @@ func (r *theoryProgressRepository) IsCompleted(ctx context.Context, userID uuid.
 	err := r.db.QueryRow(ctx, query, userID, chapterSlug, lessonSlug).Scan(&exists)
 	return exists, err
 }
+
+func (r *theoryProgressRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]model.LessonProgress, error) {
+	query := `
+	SELECT chapter_slug, lesson_slug, completed_at
+	FROM theories_progress
+	WHERE user_id = $1
+	ORDER BY completed_at
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	progress := []model.LessonProgress{}
+	for rows.Next() {
+		var p model.LessonProgress
+		if err := rows.Scan(&p.ChapterSlug, &p.LessonSlug, &p.CompletedAt); err != nil {
+			return nil, err
+		}
+		progress = append(progress, p)
+	}
+
+	return progress, rows.Err()
+}
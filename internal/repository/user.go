@@ -3,27 +3,64 @@ package repository
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/GlebMoskalev/go-path-backend/internal/model"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var (
-	UserNotFound = errors.New("user not found")
+	UserNotFound    = errors.New("user not found")
+	UserEmailTaken  = errors.New("user email already taken")
+	UserHandleTaken = errors.New("user handle already taken")
+	// UserHandleLimited — handle менялся позже, чем разрешено
+	UserHandleLimited = errors.New("user handle changed too recently")
 )
 
+// userColumns — колонки пользователя для scanUser и userFields
+const userColumns = `id, email, name, picture, role, token_version, is_active, last_login_at, created_at, updated_at, delete_after,
+		COALESCE(handle, ''), profile_public, show_stats, show_chapters, show_projects, locale`
+
+// likeEscaper экранирует спецсимволы LIKE в поисковой строке
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 type UserRepository interface {
-	Create(ctx context.Context, user *model.User) error
+	// Create создаёт пользователя вместе с первой привязкой провайдера;
+	// занятый email возвращает UserEmailTaken, занятая привязка — IdentityExists
+	Create(ctx context.Context, user *model.User, identity *model.UserIdentity) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
-	GetByGoogleID(ctx context.Context, googleID string) (*model.User, error)
-	Update(ctx context.Context, user *model.User) error
+	GetByIdentity(ctx context.Context, provider, subject string) (*model.User, error)
+	GetByHandle(ctx context.Context, handle string) (*model.User, error)
+	GetAuthState(ctx context.Context, id uuid.UUID) (*model.UserAuthState, error)
+	// Update одним запросом сохраняет имя, аватар, настройки приватности, язык и handle.
+	// Новый handle принимается, если прошлое изменение было не позже changedBefore, иначе
+	// UserHandleLimited; занятый handle — UserHandleTaken. Пустой handle снимает адрес без ограничения
+	Update(ctx context.Context, user *model.User, changedBefore time.Time) error
 	UpdateLastLogin(ctx context.Context, userID uuid.UUID) error
 	IncrementTokenVersion(ctx context.Context, userID uuid.UUID) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	// ScheduleDeletion блокирует аккаунт, отзывает сессии и выданные токены
+	// и назначает окончательное удаление на deleteAfter
+	ScheduleDeletion(ctx context.Context, id uuid.UUID, deleteAfter time.Time) (*model.User, error)
+	// CancelDeletion восстанавливает аккаунт, если срок удаления ещё не наступил; иначе UserNotFound
+	CancelDeletion(ctx context.Context, id uuid.UUID) (*model.User, error)
+	// PurgeDeleted удаляет до limit аккаунтов с наступившим сроком вместе со всеми данными,
+	// пишет запись в account_deletions и возвращает id удалённых
+	PurgeDeleted(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error)
+	// List ищет пользователей для админки и возвращает страницу вместе с общим числом
+	List(ctx context.Context, filter model.UserFilter) ([]model.User, int, error)
+	SetRole(ctx context.Context, id uuid.UUID, role model.Role) (*model.User, error)
+	// SetActive включает или блокирует аккаунт; блокировка делает недействительными
+	// выданные токены и отзывает сессии. Оба действия снимают запланированное удаление:
+	// иначе заблокированный пользователь снял бы блокировку, восстановив аккаунт при входе
+	SetActive(ctx context.Context, id uuid.UUID, active bool) (*model.User, error)
+	// PromoteAdmins назначает роль admin пользователям с указанными email (в нижнем регистре)
+	// и возвращает id тех, чья роль изменилась
+	PromoteAdmins(ctx context.Context, emails []string) ([]uuid.UUID, error)
 }
 
 type userRepository struct {
@@ -34,122 +71,133 @@ func NewUserRepository(db *pgxpool.Pool) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(ctx context.Context, user *model.User) error {
-	query := `
-	INSERT INTO users (id, email, name, picture, google_id, token_version, is_active, created_at, updated_at)
+func (r *userRepository) Create(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	userQuery := `
+	INSERT INTO users (id, email, name, picture, role, token_version, is_active, created_at, updated_at)
 	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (email) DO NOTHING
 	`
-
-	_, err := r.db.Exec(ctx, query,
-		user.ID, user.Email, user.Name, user.Picture, user.GoogleID, user.TokenVersion, user.IsActive, user.CreatedAt, user.UpdatedAt,
+	tag, err := tx.Exec(ctx, userQuery,
+		user.ID, user.Email, user.Name, user.Picture, user.Role, user.TokenVersion, user.IsActive, user.CreatedAt, user.UpdatedAt,
 	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return UserEmailTaken
+	}
 
-	return err
+	identityQuery := `
+	INSERT INTO user_identities (provider, subject, user_id, email, created_at)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT DO NOTHING
+	`
+	identity.UserID = user.ID
+	identity.CreatedAt = user.CreatedAt
+	tag, err = tx.Exec(ctx, identityQuery, identity.Provider, identity.Subject, identity.UserID, identity.Email, identity.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return IdentityExists
+	}
+
+	return tx.Commit(ctx)
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
 	query := `
-	SELECT id, email, name, picture, google_id, token_version, is_active, last_login_at, created_at, updated_at
+	SELECT ` + userColumns + `
 	FROM users
-	WHERE id = $1 and is_active = TRUE
+	WHERE id = $1
 	`
 
-	user := &model.User{}
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Name,
-		&user.Picture,
-		&user.GoogleID,
-		&user.TokenVersion,
-		&user.IsActive,
-		&user.LastLoginAt,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, UserNotFound
-		}
-		return nil, err
-	}
-	return user, nil
+	return scanUser(r.db.QueryRow(ctx, query, id))
 }
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	query := `
-	SELECT id, email, name, picture, google_id, token_version, is_active, last_login_at, created_at, updated_at
+	SELECT ` + userColumns + `
 	FROM users
-	WHERE email = $1 AND is_active = true
+	WHERE email = $1
 	`
 
-	user := &model.User{}
-	err := r.db.QueryRow(ctx, query, email).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Name,
-		&user.Picture,
-		&user.GoogleID,
-		&user.TokenVersion,
-		&user.IsActive,
-		&user.LastLoginAt,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	return scanUser(r.db.QueryRow(ctx, query, email))
+}
 
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, UserNotFound
-		}
-		return nil, err
-	}
+func (r *userRepository) GetByIdentity(ctx context.Context, provider, subject string) (*model.User, error) {
+	query := `
+	SELECT ` + userColumns + `
+	FROM users
+	WHERE id = (SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2)
+	`
 
-	return user, nil
+	return scanUser(r.db.QueryRow(ctx, query, provider, subject))
 }
 
-func (r *userRepository) GetByGoogleID(ctx context.Context, googleID string) (*model.User, error) {
+func (r *userRepository) GetByHandle(ctx context.Context, handle string) (*model.User, error) {
 	query := `
-	SELECT id, email, name, picture, google_id, token_version, is_active, last_login_at, created_at, updated_at
+	SELECT ` + userColumns + `
 	FROM users
-	WHERE google_id = $1 AND is_active = true
+	WHERE handle = $1
 	`
 
-	user := &model.User{}
-	err := r.db.QueryRow(ctx, query, googleID).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Name,
-		&user.Picture,
-		&user.GoogleID,
-		&user.TokenVersion,
-		&user.IsActive,
-		&user.LastLoginAt,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	return scanUser(r.db.QueryRow(ctx, query, handle))
+}
+
+func (r *userRepository) GetAuthState(ctx context.Context, id uuid.UUID) (*model.UserAuthState, error) {
+	query := `
+	SELECT token_version, is_active, role, locale
+	FROM users
+	WHERE id = $1
+	`
 
+	state := &model.UserAuthState{}
+	err := r.db.QueryRow(ctx, query, id).Scan(&state.TokenVersion, &state.IsActive, &state.Role, &state.Locale)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, UserNotFound
 		}
 		return nil, err
 	}
-
-	return user, nil
+	return state, nil
 }
 
-func (r *userRepository) Update(ctx context.Context, user *model.User) error {
+func (r *userRepository) Update(ctx context.Context, user *model.User, changedBefore time.Time) error {
 	query := `
-    UPDATE users
-    SET name = $2, picture = COALESCE(NULLIF($3, ''), picture), updated_at = $4
-    WHERE id = $1
-    `
+	UPDATE users
+	SET name = $2, picture = COALESCE(NULLIF($3, ''), picture), updated_at = $4,
+		profile_public = $5, show_stats = $6, show_chapters = $7, show_projects = $8,
+		locale = $11,
+		handle = NULLIF($9, ''),
+		handle_changed_at = CASE WHEN handle IS DISTINCT FROM NULLIF($9, '') THEN $4 ELSE handle_changed_at END
+	WHERE id = $1
+		AND (handle IS NOT DISTINCT FROM NULLIF($9, '') OR $9 = ''
+			OR handle_changed_at IS NULL OR handle_changed_at <= $10)
+	`
 	user.UpdatedAt = time.Now()
 
-	_, err := r.db.Exec(ctx, query, user.ID, user.Name, user.Picture, user.UpdatedAt)
-
-	return err
+	tag, err := r.db.Exec(ctx, query, user.ID, user.Name, user.Picture, user.UpdatedAt,
+		user.Privacy.Public, user.Privacy.ShowStats, user.Privacy.ShowChapters, user.Privacy.ShowProjects,
+		user.Handle, changedBefore, user.Locale)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		// 23505 — unique_violation
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return UserHandleTaken
+		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return UserHandleLimited
+	}
+	return nil
 }
 
 func (r *userRepository) UpdateLastLogin(ctx context.Context, userID uuid.UUID) error {
@@ -177,14 +225,199 @@ func (r *userRepository) IncrementTokenVersion(ctx context.Context, userID uuid.
 	return err
 }
 
-func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (r *userRepository) ScheduleDeletion(ctx context.Context, id uuid.UUID, deleteAfter time.Time) (*model.User, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 	UPDATE users
-	SET is_active = false, updated_at = $2
+	SET is_active = false,
+		token_version = token_version + 1,
+		deletion_requested_at = $3,
+		delete_after = $2,
+		updated_at = $3
 	WHERE id = $1
+	RETURNING ` + userColumns + `
 	`
+	user, err := scanUser(tx.QueryRow(ctx, query, id, deleteAfter, time.Now()))
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := r.db.Exec(ctx, query, id, time.Now())
+	_, err = tx.Exec(ctx, `UPDATE sessions SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return nil, err
+	}
 
-	return err
+	return user, tx.Commit(ctx)
+}
+
+func (r *userRepository) CancelDeletion(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	query := `
+	UPDATE users
+	SET is_active = true, deletion_requested_at = NULL, delete_after = NULL, updated_at = $2
+	WHERE id = $1 AND delete_after > $2
+	RETURNING ` + userColumns + `
+	`
+
+	return scanUser(r.db.QueryRow(ctx, query, id, time.Now()))
+}
+
+func (r *userRepository) PurgeDeleted(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	// SKIP LOCKED позволяет нескольким экземплярам сервера чистить параллельно
+	query := `
+	WITH purged AS (
+		DELETE FROM users
+		WHERE id IN (
+			SELECT id FROM users
+			WHERE delete_after <= $1
+			ORDER BY delete_after
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, deletion_requested_at, delete_after
+	)
+	INSERT INTO account_deletions (user_id, requested_at, scheduled_at, purged_at)
+	SELECT id, deletion_requested_at, delete_after, $1 FROM purged
+	RETURNING user_id
+	`
+
+	rows, err := r.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+func (r *userRepository) List(ctx context.Context, filter model.UserFilter) ([]model.User, int, error) {
+	query := `
+	SELECT ` + userColumns + `, count(*) OVER ()
+	FROM users
+	WHERE ($1 = '' OR email ILIKE $1 OR name ILIKE $1)
+		AND ($2 = '' OR role = $2)
+		AND ($3::boolean IS NULL OR is_active = $3)
+	ORDER BY created_at DESC, id
+	LIMIT $4 OFFSET $5
+	`
+
+	pattern := ""
+	if filter.Query != "" {
+		pattern = "%" + likeEscaper.Replace(filter.Query) + "%"
+	}
+
+	rows, err := r.db.Query(ctx, query, pattern, string(filter.Role), filter.Active, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []model.User{}
+	total := 0
+	for rows.Next() {
+		var user model.User
+		err := rows.Scan(append(userFields(&user), &total)...)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+
+	return users, total, rows.Err()
+}
+
+func (r *userRepository) SetRole(ctx context.Context, id uuid.UUID, role model.Role) (*model.User, error) {
+	query := `
+	UPDATE users
+	SET role = $2, updated_at = $3
+	WHERE id = $1
+	RETURNING ` + userColumns + `
+	`
+
+	return scanUser(r.db.QueryRow(ctx, query, id, role, time.Now()))
+}
+
+func (r *userRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) (*model.User, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+	UPDATE users
+	SET is_active = $2,
+		token_version = CASE WHEN $2 THEN token_version ELSE token_version + 1 END,
+		deletion_requested_at = NULL,
+		delete_after = NULL,
+		updated_at = $3
+	WHERE id = $1
+	RETURNING ` + userColumns + `
+	`
+	user, err := scanUser(tx.QueryRow(ctx, query, id, active, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	if !active {
+		_, err = tx.Exec(ctx, `UPDATE sessions SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return user, tx.Commit(ctx)
+}
+
+func (r *userRepository) PromoteAdmins(ctx context.Context, emails []string) ([]uuid.UUID, error) {
+	query := `
+	UPDATE users
+	SET role = 'admin', updated_at = $2
+	WHERE lower(email) = ANY($1) AND role <> 'admin'
+	RETURNING id
+	`
+
+	rows, err := r.db.Query(ctx, query, emails, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+func scanUser(row pgx.Row) (*model.User, error) {
+	user := &model.User{}
+	err := row.Scan(userFields(user)...)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, UserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// userFields — поля model.User в порядке userColumns
+func userFields(user *model.User) []any {
+	return []any{
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&user.Picture,
+		&user.Role,
+		&user.TokenVersion,
+		&user.IsActive,
+		&user.LastLoginAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeleteAfter,
+		&user.Handle,
+		&user.Privacy.Public,
+		&user.Privacy.ShowStats,
+		&user.Privacy.ShowChapters,
+		&user.Privacy.ShowProjects,
+		&user.Locale,
+	}
 }
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"expvar"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// Счётчики кеша публикуются через expvar (/api/admin/metrics)
+var (
+	authStateCacheHits   = expvar.NewInt("auth_state_cache_hits")
+	authStateCacheMisses = expvar.NewInt("auth_state_cache_misses")
+	authStateCacheErrors = expvar.NewInt("auth_state_cache_errors")
+)
+
+func init() {
+	expvar.Publish("auth_state_cache_hit_rate", expvar.Func(func() any {
+		hits, misses := authStateCacheHits.Value(), authStateCacheMisses.Value()
+		if hits+misses == 0 {
+			return 0.0
+		}
+		return float64(hits) / float64(hits+misses)
+	}))
+}
+
+// cachedUserRepository кеширует GetAuthState и сбрасывает кеш во всех методах,
+// которые меняют token version, роль или статус, — вызывающим не нужно помнить об инвалидации
+type cachedUserRepository struct {
+	UserRepository
+	cache AuthStateCache
+	ttl   time.Duration
+}
+
+// NewCachedUserRepository оборачивает репозиторий кешем; при ttl <= 0 возвращает его как есть.
+// Ошибки Redis не ломают запрос: состояние читается из базы.
+func NewCachedUserRepository(repo UserRepository, cache AuthStateCache, ttl time.Duration) UserRepository {
+	if ttl <= 0 {
+		return repo
+	}
+	return &cachedUserRepository{UserRepository: repo, cache: cache, ttl: ttl}
+}
+
+func (r *cachedUserRepository) GetAuthState(ctx context.Context, id uuid.UUID) (*model.UserAuthState, error) {
+	state, err := r.cache.Get(ctx, id)
+	if err != nil {
+		authStateCacheErrors.Add(1)
+	}
+	if state != nil {
+		authStateCacheHits.Add(1)
+		return state, nil
+	}
+	authStateCacheMisses.Add(1)
+
+	state, err = r.UserRepository.GetAuthState(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.cache.Set(ctx, id, state, r.ttl); err != nil {
+		authStateCacheErrors.Add(1)
+	}
+	return state, nil
+}
+
+// Update меняет язык, который кешируется вместе с состоянием
+func (r *cachedUserRepository) Update(ctx context.Context, user *model.User, changedBefore time.Time) error {
+	err := r.UserRepository.Update(ctx, user, changedBefore)
+	r.invalidate(ctx, user.ID)
+	return err
+}
+
+func (r *cachedUserRepository) IncrementTokenVersion(ctx context.Context, userID uuid.UUID) error {
+	err := r.UserRepository.IncrementTokenVersion(ctx, userID)
+	r.invalidate(ctx, userID)
+	return err
+}
+
+func (r *cachedUserRepository) ScheduleDeletion(ctx context.Context, id uuid.UUID, deleteAfter time.Time) (*model.User, error) {
+	user, err := r.UserRepository.ScheduleDeletion(ctx, id, deleteAfter)
+	r.invalidate(ctx, id)
+	return user, err
+}
+
+func (r *cachedUserRepository) CancelDeletion(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	user, err := r.UserRepository.CancelDeletion(ctx, id)
+	r.invalidate(ctx, id)
+	return user, err
+}
+
+func (r *cachedUserRepository) PurgeDeleted(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	purged, err := r.UserRepository.PurgeDeleted(ctx, now, limit)
+	for _, id := range purged {
+		r.invalidate(ctx, id)
+	}
+	return purged, err
+}
+
+func (r *cachedUserRepository) SetRole(ctx context.Context, id uuid.UUID, role model.Role) (*model.User, error) {
+	user, err := r.UserRepository.SetRole(ctx, id, role)
+	r.invalidate(ctx, id)
+	return user, err
+}
+
+func (r *cachedUserRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) (*model.User, error) {
+	user, err := r.UserRepository.SetActive(ctx, id, active)
+	r.invalidate(ctx, id)
+	return user, err
+}
+
+func (r *cachedUserRepository) PromoteAdmins(ctx context.Context, emails []string) ([]uuid.UUID, error) {
+	promoted, err := r.UserRepository.PromoteAdmins(ctx, emails)
+	for _, id := range promoted {
+		r.invalidate(ctx, id)
+	}
+	return promoted, err
+}
+
+// invalidate удаляет запись после записи в базу; промах просто перечитает состояние
+func (r *cachedUserRepository) invalidate(ctx context.Context, userID uuid.UUID) {
+	if err := r.cache.Delete(ctx, userID); err != nil {
+		authStateCacheErrors.Add(1)
+	}
+}
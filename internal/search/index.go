@@ -0,0 +1,239 @@
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// titleWeight — во сколько раз совпадение в заголовке весомее совпадения в тексте
+	titleWeight = 3
+	bm25K1      = 1.2
+	bm25B       = 0.75
+	// snippetLen — примерная половина длины фрагмента в байтах
+	snippetLen = 160
+)
+
+var stopWords = map[string]bool{
+	"и": true, "в": true, "во": true, "не": true, "что": true, "он": true, "на": true, "я": true,
+	"с": true, "со": true, "как": true, "а": true, "то": true, "все": true, "она": true, "так": true,
+	"его": true, "но": true, "да": true, "ты": true, "к": true, "у": true, "же": true, "вы": true,
+	"за": true, "бы": true, "по": true, "только": true, "ее": true, "мне": true, "было": true,
+	"вот": true, "от": true, "меня": true, "еще": true, "нет": true, "о": true, "из": true,
+	"ему": true, "ли": true, "если": true, "или": true, "ни": true, "быть": true, "был": true,
+	"до": true, "для": true, "это": true, "где": true, "при": true, "про": true, "об": true,
+	"the": true, "a": true, "an": true, "and": true, "or": true, "of": true, "to": true,
+	"in": true, "on": true, "for": true, "is": true, "are": true, "was": true, "be": true,
+	"it": true, "this": true, "that": true, "with": true, "as": true, "by": true, "at": true,
+	"from": true, "where": true, "what": true, "about": true,
+}
+
+// Document — единица поиска: урок, задача, вопрос квиза или шаг проекта
+type Document struct {
+	Type        string
+	ChapterSlug string
+	Slug        string
+	Title       string
+	Body        string
+	Locale      string
+}
+
+type Hit struct {
+	Document Document
+	Score    float64
+	Snippet  string
+}
+
+type posting struct {
+	doc int
+	tf  float64
+}
+
+// Index — неизменяемый инвертированный индекс с ранжированием BM25
+type Index struct {
+	docs     []Document
+	postings map[string][]posting
+	docLen   []float64
+	avgLen   float64
+}
+
+func NewIndex(docs []Document) *Index {
+	idx := &Index{
+		docs:     docs,
+		postings: make(map[string][]posting),
+		docLen:   make([]float64, len(docs)),
+	}
+
+	var total float64
+	for i, d := range docs {
+		freq := make(map[string]float64)
+		for _, t := range tokenize(d.Title) {
+			freq[t.term] += titleWeight
+			idx.docLen[i] += titleWeight
+		}
+		for _, t := range tokenize(d.Body) {
+			freq[t.term]++
+			idx.docLen[i]++
+		}
+		for term, tf := range freq {
+			idx.postings[term] = append(idx.postings[term], posting{doc: i, tf: tf})
+		}
+		total += idx.docLen[i]
+	}
+	if len(docs) > 0 {
+		idx.avgLen = total / float64(len(docs))
+	}
+
+	return idx
+}
+
+func (idx *Index) Len() int {
+	return len(idx.docs)
+}
+
+// Search возвращает документы, содержащие хотя бы один термин запроса, по убыванию релевантности.
+// filter == nil пропускает все документы.
+func (idx *Index) Search(query string, filter func(Document) bool, limit int) []Hit {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	n := float64(len(idx.docs))
+	scores := make(map[int]float64)
+	for term := range terms {
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for _, p := range postings {
+			norm := p.tf + bm25K1*(1-bm25B+bm25B*idx.docLen[p.doc]/idx.avgLen)
+			scores[p.doc] += idf * p.tf * (bm25K1 + 1) / norm
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for doc, score := range scores {
+		if filter != nil && !filter(idx.docs[doc]) {
+			continue
+		}
+		hits = append(hits, Hit{Document: idx.docs[doc], Score: score})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Document.Title < hits[j].Document.Title
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	for i := range hits {
+		hits[i].Snippet = snippet(hits[i].Document.Body, terms)
+	}
+
+	return hits
+}
+
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize разбивает текст на слова и приводит их к основе; стоп-слова отбрасываются
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	flush := func(end int) {
+		if start == -1 {
+			return
+		}
+		word := strings.ToLower(text[start:end])
+		if !stopWords[word] {
+			tokens = append(tokens, token{term: Stem(word), start: start, end: end})
+		}
+		start = -1
+	}
+
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+
+	return tokens
+}
+
+func queryTerms(query string) map[string]bool {
+	terms := make(map[string]bool)
+	for _, t := range tokenize(query) {
+		terms[t.term] = true
+	}
+	return terms
+}
+
+// snippet вырезает фрагмент текста вокруг первого совпадения и оборачивает совпадения в <mark>.
+// Текст фрагмента экранируется, так что результат можно вставлять как HTML.
+func snippet(body string, terms map[string]bool) string {
+	tokens := tokenize(body)
+
+	first := -1
+	for _, t := range tokens {
+		if terms[t.term] {
+			first = t.start
+			break
+		}
+	}
+
+	from, to := 0, len(body)
+	if first != -1 {
+		from = first - snippetLen/3
+	}
+	if from < 0 {
+		from = 0
+	}
+	for from > 0 && !utf8.RuneStart(body[from]) {
+		from--
+	}
+	if to-from > snippetLen*2 {
+		to = from + snippetLen*2
+		for to < len(body) && !utf8.RuneStart(body[to]) {
+			to++
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("… ")
+	}
+	pos := from
+	for _, t := range tokens {
+		if t.start < from || t.end > to || !terms[t.term] {
+			continue
+		}
+		b.WriteString(html.EscapeString(body[pos:t.start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(body[t.start:t.end]))
+		b.WriteString("</mark>")
+		pos = t.end
+	}
+	b.WriteString(html.EscapeString(body[pos:to]))
+	if to < len(body) {
+		b.WriteString(" …")
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
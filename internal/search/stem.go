@@ -0,0 +1,334 @@
+package search
+
+import "strings"
+
+// Stem приводит слово к основе: кириллица — по алгоритму Snowball для русского,
+// латиница — по алгоритму Портера для английского. Слово должно быть в нижнем регистре.
+func Stem(word string) string {
+	for _, r := range word {
+		if r >= 'а' && r <= 'я' || r == 'ё' {
+			return stemRussian(word)
+		}
+		if r >= 'a' && r <= 'z' {
+			return stemEnglish(word)
+		}
+	}
+	return word
+}
+
+// --- русский (Snowball) ---
+
+var (
+	ruPerfectiveGerund1 = []string{"вшись", "вши", "в"}
+	ruPerfectiveGerund2 = []string{"ившись", "ывшись", "ивши", "ывши", "ив", "ыв"}
+	ruReflexive         = []string{"ся", "сь"}
+	ruAdjective         = []string{
+		"ими", "ыми", "его", "ого", "ему", "ому",
+		"ее", "ие", "ые", "ое", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"их", "ых", "ую", "юю", "ая", "яя", "ою", "ею",
+	}
+	ruParticiple1 = []string{"ем", "нн", "вш", "ющ", "щ"}
+	ruParticiple2 = []string{"ивш", "ывш", "ующ"}
+	ruVerb1       = []string{"ете", "йте", "ешь", "нно", "ла", "на", "ли", "ем", "ло", "но", "ет", "ют", "ны", "ть", "й", "л", "н"}
+	ruVerb2       = []string{
+		"ейте", "уйте", "ила", "ыла", "ена", "ите", "или", "ыли", "ило", "ыло", "ено",
+		"ует", "уют", "ены", "ить", "ыть", "ишь", "ей", "уй", "ил", "ыл", "им", "ым",
+		"ен", "ят", "ит", "ыт", "ую", "ю",
+	}
+	ruNoun = []string{
+		"иями", "ями", "ами", "ией", "иям", "ием", "иях",
+		"ев", "ов", "ие", "ье", "еи", "ии", "ей", "ой", "ий", "ям", "ем", "ам", "ом",
+		"ах", "ях", "ию", "ью", "ия", "ья",
+		"а", "е", "и", "й", "о", "у", "ы", "ь", "ю", "я",
+	}
+	ruSuperlative  = []string{"ейше", "ейш"}
+	ruDerivational = []string{"ость", "ост"}
+	ruVowels       = "аеиоуыэюя"
+	ruPrecedingAYa = "ая"
+)
+
+func stemRussian(word string) string {
+	w := []rune(strings.ReplaceAll(word, "ё", "е"))
+	if len(w) <= 2 {
+		return string(w)
+	}
+
+	rv := len(w)
+	for i, r := range w {
+		if strings.ContainsRune(ruVowels, r) {
+			rv = i + 1
+			break
+		}
+	}
+	r2 := regionAfter(w, regionAfter(w, 0))
+
+	// шаг 1
+	var ok bool
+	if w, ok = cutEnding(w, rv, ruPerfectiveGerund1, true); !ok {
+		if w, ok = cutEnding(w, rv, ruPerfectiveGerund2, false); !ok {
+			w, _ = cutEnding(w, rv, ruReflexive, false)
+
+			if w, ok = cutEnding(w, rv, ruAdjective, false); ok {
+				if w, ok = cutEnding(w, rv, ruParticiple1, true); !ok {
+					w, _ = cutEnding(w, rv, ruParticiple2, false)
+				}
+			} else if w, ok = cutEnding(w, rv, ruVerb1, true); !ok {
+				if w, ok = cutEnding(w, rv, ruVerb2, false); !ok {
+					w, _ = cutEnding(w, rv, ruNoun, false)
+				}
+			}
+		}
+	}
+
+	// шаг 2
+	w, _ = cutEnding(w, rv, []string{"и"}, false)
+
+	// шаг 3
+	w, _ = cutEnding(w, r2, ruDerivational, false)
+
+	// шаг 4
+	w, superlative := cutEnding(w, rv, ruSuperlative, false)
+	switch {
+	case hasSuffix(w, "нн") && len(w)-2 >= rv:
+		w = w[:len(w)-1]
+	case !superlative:
+		w, _ = cutEnding(w, rv, []string{"ь"}, false)
+	}
+
+	return string(w)
+}
+
+// regionAfter возвращает начало региона после первой пары «гласная, согласная», начиная с from
+func regionAfter(w []rune, from int) int {
+	for i := from + 1; i < len(w); i++ {
+		if !strings.ContainsRune(ruVowels, w[i]) && strings.ContainsRune(ruVowels, w[i-1]) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
+
+// cutEnding удаляет первое (самое длинное) окончание из endings, целиком лежащее в регионе start.
+// Если needAYa, перед окончанием должна стоять «а» или «я», которая остаётся в слове.
+func cutEnding(w []rune, start int, endings []string, needAYa bool) ([]rune, bool) {
+	for _, e := range endings {
+		n := len([]rune(e))
+		if !hasSuffix(w, e) || len(w)-n < start {
+			continue
+		}
+		if needAYa {
+			pos := len(w) - n - 1
+			if pos < start || !strings.ContainsRune(ruPrecedingAYa, w[pos]) {
+				continue
+			}
+		}
+		return w[:len(w)-n], true
+	}
+	return w, false
+}
+
+func hasSuffix(w []rune, suffix string) bool {
+	s := []rune(suffix)
+	if len(s) > len(w) {
+		return false
+	}
+	for i := range s {
+		if w[len(w)-len(s)+i] != s[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// --- английский (Porter) ---
+
+func stemEnglish(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	w := []byte(word)
+
+	w = enStep1a(w)
+	w = enStep1b(w)
+	w = enStep1c(w)
+	w = enReplace(w, enStep2Rules, 0)
+	w = enReplace(w, enStep3Rules, 0)
+	w = enStep4(w)
+	w = enStep5(w)
+
+	return string(w)
+}
+
+var enStep2Rules = [][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"},
+	{"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"},
+	{"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"}, {"ousness", "ous"},
+	{"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"}, {"logi", "log"},
+}
+
+var enStep3Rules = [][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+var enStep4Suffixes = []string{
+	"ement", "ance", "ence", "able", "ible", "ment", "ant", "ent", "ion",
+	"ism", "ate", "iti", "ous", "ive", "ize", "al", "er", "ic", "ou",
+}
+
+func enConsonant(w []byte, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !enConsonant(w, i-1)
+	}
+	return true
+}
+
+// enMeasure — число последовательностей VC в w
+func enMeasure(w []byte) int {
+	m := 0
+	i := 0
+	for i < len(w) && enConsonant(w, i) {
+		i++
+	}
+	for i < len(w) {
+		for i < len(w) && !enConsonant(w, i) {
+			i++
+		}
+		if i >= len(w) {
+			break
+		}
+		m++
+		for i < len(w) && enConsonant(w, i) {
+			i++
+		}
+	}
+	return m
+}
+
+func enHasVowel(w []byte) bool {
+	for i := range w {
+		if !enConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func enDoubleConsonant(w []byte) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && enConsonant(w, n-1)
+}
+
+// enCVC — слово оканчивается на согласная-гласная-согласная, последняя не w, x, y
+func enCVC(w []byte) bool {
+	n := len(w)
+	if n < 3 || !enConsonant(w, n-3) || enConsonant(w, n-2) || !enConsonant(w, n-1) {
+		return false
+	}
+	last := w[n-1]
+	return last != 'w' && last != 'x' && last != 'y'
+}
+
+func enEnds(w []byte, suffix string) bool {
+	return len(w) >= len(suffix) && string(w[len(w)-len(suffix):]) == suffix
+}
+
+func enStep1a(w []byte) []byte {
+	switch {
+	case enEnds(w, "sses"), enEnds(w, "ies"):
+		return w[:len(w)-2]
+	case enEnds(w, "ss"):
+		return w
+	case enEnds(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+func enStep1b(w []byte) []byte {
+	if enEnds(w, "eed") {
+		if enMeasure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+
+	var stem []byte
+	switch {
+	case enEnds(w, "ed") && enHasVowel(w[:len(w)-2]):
+		stem = w[:len(w)-2]
+	case enEnds(w, "ing") && enHasVowel(w[:len(w)-3]):
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+
+	switch {
+	case enEnds(stem, "at"), enEnds(stem, "bl"), enEnds(stem, "iz"):
+		return append(stem, 'e')
+	case enDoubleConsonant(stem):
+		last := stem[len(stem)-1]
+		if last != 'l' && last != 's' && last != 'z' {
+			return stem[:len(stem)-1]
+		}
+	case enMeasure(stem) == 1 && enCVC(stem):
+		return append(stem, 'e')
+	}
+	return stem
+}
+
+func enStep1c(w []byte) []byte {
+	if enEnds(w, "y") && enHasVowel(w[:len(w)-1]) {
+		w[len(w)-1] = 'i'
+	}
+	return w
+}
+
+func enReplace(w []byte, rules [][2]string, minMeasure int) []byte {
+	for _, rule := range rules {
+		if !enEnds(w, rule[0]) {
+			continue
+		}
+		stem := w[:len(w)-len(rule[0])]
+		if enMeasure(stem) > minMeasure {
+			return append(stem, rule[1]...)
+		}
+		return w
+	}
+	return w
+}
+
+func enStep4(w []byte) []byte {
+	for _, suffix := range enStep4Suffixes {
+		if !enEnds(w, suffix) {
+			continue
+		}
+		stem := w[:len(w)-len(suffix)]
+		if enMeasure(stem) <= 1 {
+			return w
+		}
+		if suffix == "ion" && !(enEnds(stem, "s") || enEnds(stem, "t")) {
+			return w
+		}
+		return stem
+	}
+	return w
+}
+
+func enStep5(w []byte) []byte {
+	if enEnds(w, "e") {
+		stem := w[:len(w)-1]
+		m := enMeasure(stem)
+		if m > 1 || m == 1 && !enCVC(stem) {
+			w = stem
+		}
+	}
+	if enEnds(w, "ll") && enMeasure(w) > 1 {
+		w = w[:len(w)-1]
+	}
+	return w
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/GlebMoskalev/go-path-backend/internal/config"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
 	"github.com/google/uuid"
 	"github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
@@ -62,7 +63,7 @@ func NewAIService(
 }
 
 func (s *AIService) AnalyzePassedCodeTask(ctx context.Context, chapterSlug, taskSlug, code string, userID uuid.UUID) (string, error) {
-	task, err := s.serviceTask.GetTask(ctx, chapterSlug, taskSlug, &userID)
+	task, err := s.serviceTask.GetTask(ctx, chapterSlug, taskSlug, &userID, model.DefaultLocale)
 	if err != nil {
 		s.log.Error("failed get task", zap.Error(err), zap.String("chapterSlug", chapterSlug), zap.String("taskSlug", taskSlug))
 		return "", err
@@ -119,7 +120,7 @@ func (s *AIService) AnalyzePassedCodeTask(ctx context.Context, chapterSlug, task
 }
 
 func (s *AIService) AnalyzePassedCodeProject(ctx context.Context, projectSlug, stepSlug, code string, userID uuid.UUID) (string, error) {
-	step, err := s.serviceProject.GetStep(ctx, projectSlug, stepSlug, &userID)
+	step, err := s.serviceProject.GetStep(ctx, projectSlug, stepSlug, &userID, model.DefaultLocale)
 	if err != nil {
 		s.log.Error("failed get project step",
 			zap.Error(err),
@@ -176,7 +177,7 @@ func (s *AIService) AnalyzePassedCodeProject(ctx context.Context, projectSlug, s
 }
 
 func (s *AIService) AnalyzeErrorTask(ctx context.Context, chapterSlug, taskSlug, code, errorOutput string, userID uuid.UUID) (string, error) {
-	task, err := s.serviceTask.GetTask(ctx, chapterSlug, taskSlug, &userID)
+	task, err := s.serviceTask.GetTask(ctx, chapterSlug, taskSlug, &userID, model.DefaultLocale)
 	if err != nil {
 		s.log.Error("failed get task", zap.Error(err), zap.String("chapterSlug", chapterSlug), zap.String("taskSlug", taskSlug))
 		return "", err
@@ -233,7 +234,7 @@ func (s *AIService) AnalyzeErrorTask(ctx context.Context, chapterSlug, taskSlug,
 }
 
 func (s *AIService) AnalyzeErrorProject(ctx context.Context, projectSlug, stepSlug, code, errorOutput string, userID uuid.UUID) (string, error) {
-	step, err := s.serviceProject.GetStep(ctx, projectSlug, stepSlug, &userID)
+	step, err := s.serviceProject.GetStep(ctx, projectSlug, stepSlug, &userID, model.DefaultLocale)
 	if err != nil {
 		s.log.Error("failed get project step",
 			zap.Error(err),
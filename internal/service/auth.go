@@ -3,141 +3,368 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/GlebMoskalev/go-path-backend/internal/config"
 	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/oauth"
 	"github.com/GlebMoskalev/go-path-backend/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 )
 
+var (
+	ErrUnknownProvider  = errors.New("unknown oauth provider")
+	ErrInvalidState     = errors.New("invalid or expired state")
+	ErrEmailNotVerified = errors.New("provider email is missing or not verified")
+	// ErrEmailInUse — email занят другим аккаунтом; привязать провайдера можно после входа в него
+	ErrEmailInUse       = errors.New("email already used by another account")
+	ErrIdentityInUse    = errors.New("identity linked to another account")
+	ErrProviderLinked   = errors.New("provider already linked")
+	ErrIdentityNotFound = errors.New("identity not found")
+	ErrLastIdentity     = errors.New("cannot unlink the only identity")
+	// ErrInvalidAuthCode — код неизвестен, истёк, уже обменян или не совпал code_verifier
+	ErrInvalidAuthCode      = errors.New("invalid or expired authorization code")
+	ErrUnsupportedChallenge = errors.New("unsupported code challenge method")
+	ErrAccountDisabled      = errors.New("account is deactivated")
+	// ErrInvalidLinkCode — код привязки неизвестен, истёк или выдан другому пользователю
+	ErrInvalidLinkCode = errors.New("invalid or expired link code")
+	// ErrAccountPendingDeletion — аккаунт удалён, но его можно восстановить, повторив вход с restore
+	ErrAccountPendingDeletion = errors.New("account is scheduled for deletion")
+)
+
+// authCodeTTL — время жизни одноразового кода из редиректа на фронтенд
+const authCodeTTL = time.Minute
+
 type AuthService struct {
 	log                *zap.Logger
 	userRepo           repository.UserRepository
+	identityRepo       repository.IdentityRepository
 	stateRepo          repository.StateRepository
-	oauth2Config       *oauth2.Config
-	jwtSecret          []byte
+	authCodeRepo       repository.AuthCodeRepository
+	linkCodeRepo       repository.LinkCodeRepository
+	sessionRepo        repository.SessionRepository
+	revokedRepo        repository.RevokedSessionRepository
+	providers          map[string]oauth.Provider
+	keys               *JWTKeys
+	adminEmails        []string
 	accessTTl          time.Duration
 	refreshTTL         time.Duration
-	userInfoURL        string
 	frontedCallbackURL string
 }
 
 func NewAuthService(
-	log *zap.Logger, userRepo repository.UserRepository, stateRepo repository.StateRepository,
-	googleCfg config.GoogleOAuthConfig, jwtCfg config.JWTConfig,
+	log *zap.Logger, userRepo repository.UserRepository, identityRepo repository.IdentityRepository,
+	stateRepo repository.StateRepository, authCodeRepo repository.AuthCodeRepository, linkCodeRepo repository.LinkCodeRepository,
+	sessionRepo repository.SessionRepository, revokedRepo repository.RevokedSessionRepository,
+	providers []oauth.Provider, frontedCallbackURL string, keys *JWTKeys, jwtCfg config.JWTConfig, adminEmails []string,
 ) *AuthService {
-	return &AuthService{
-		log:       log,
-		userRepo:  userRepo,
-		stateRepo: stateRepo,
-		jwtSecret: []byte(jwtCfg.Secret),
-		oauth2Config: &oauth2.Config{
-			ClientID:     googleCfg.ClientID,
-			ClientSecret: googleCfg.ClientSecret,
-			RedirectURL:  googleCfg.CallbackURL,
-			Endpoint:     google.Endpoint,
-			Scopes:       []string{"openid", "email", "profile"},
-		},
-		userInfoURL:        googleCfg.UserInfoURL,
+	s := &AuthService{
+		log:                log,
+		userRepo:           userRepo,
+		identityRepo:       identityRepo,
+		stateRepo:          stateRepo,
+		authCodeRepo:       authCodeRepo,
+		linkCodeRepo:       linkCodeRepo,
+		sessionRepo:        sessionRepo,
+		revokedRepo:        revokedRepo,
+		providers:          make(map[string]oauth.Provider, len(providers)),
+		keys:               keys,
+		adminEmails:        adminEmails,
 		accessTTl:          jwtCfg.AccessTTL,
 		refreshTTL:         jwtCfg.RefreshTTL,
-		frontedCallbackURL: googleCfg.FrontedCallbackURL,
+		frontedCallbackURL: frontedCallbackURL,
 	}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+	}
+	return s
+}
+
+// GetLoginURL возвращает ссылку на вход через провайдера. С codeChallenge код из
+// редиректа обменяется на токены только вместе с соответствующим code_verifier (PKCE).
+// Метод задаётся явно: по RFC 7636 без него подразумевается plain, который не поддерживается.
+func (s *AuthService) GetLoginURL(ctx context.Context, provider, codeChallenge, challengeMethod string) (string, error) {
+	if codeChallenge != "" && challengeMethod != "S256" {
+		return "", ErrUnsupportedChallenge
+	}
+	return s.authURL(ctx, model.OAuthState{Provider: provider, CodeChallenge: codeChallenge})
+}
+
+// GetLinkURL возвращает ссылку, после возврата с которой провайдер привязывается к userID
+func (s *AuthService) GetLinkURL(ctx context.Context, userID uuid.UUID, provider string) (string, error) {
+	return s.authURL(ctx, model.OAuthState{Provider: provider, LinkUserID: &userID})
 }
 
-func (s *AuthService) GetGoogleLoginURL(ctx context.Context) (string, error) {
+func (s *AuthService) authURL(ctx context.Context, data model.OAuthState) (string, error) {
+	p, ok := s.providers[data.Provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
 	state, err := generateState()
 	if err != nil {
 		s.log.Error("failed to generate state", zap.Error(err))
 		return "", fmt.Errorf("failed to generate state: %w", err)
 	}
 
-	if err := s.stateRepo.Save(ctx, state, 10*time.Minute); err != nil {
+	if err := s.stateRepo.Save(ctx, state, data, 10*time.Minute); err != nil {
 		s.log.Error("failed to save state", zap.Error(err))
 		return "", fmt.Errorf("failed to save state: %w", err)
 	}
-	return s.oauth2Config.AuthCodeURL(state, oauth2.AccessTypeOffline), nil
+	return p.AuthURL(state), nil
 }
 
-func (s *AuthService) HandleGoogleCallback(ctx context.Context, code, state string) (string, error) {
-	valid, err := s.stateRepo.Validate(ctx, state)
+// HandleCallback завершает вход или привязку и возвращает адрес фронтенда для редиректа
+func (s *AuthService) HandleCallback(ctx context.Context, provider, code, state string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	data, err := s.stateRepo.Take(ctx, state)
 	if err != nil {
 		s.log.Error("failed to validate state", zap.Error(err))
 		return "", fmt.Errorf("validate state: %w", err)
 	}
-	if !valid {
+	if data == nil || data.Provider != provider {
 		s.log.Warn("invalid or expired oauth state", zap.String("state", state))
-		return "", errors.New("invalid or expired state")
+		return "", ErrInvalidState
 	}
 
-	token, err := s.oauth2Config.Exchange(ctx, code)
+	token, err := p.Exchange(ctx, code)
 	if err != nil {
-		s.log.Error("failed to exchange code", zap.Error(err))
+		s.log.Error("failed to exchange code", zap.String("provider", provider), zap.Error(err))
 		return "", fmt.Errorf("exchange code: %w", err)
 	}
 
-	userInfo, err := s.getGoogleUserInfo(ctx, token.AccessToken)
+	profile, err := p.FetchProfile(ctx, token)
 	if err != nil {
-		s.log.Error("failed to get google user info", zap.Error(err))
-		return "", fmt.Errorf("get google user info: %w", err)
+		s.log.Error("failed to get user info", zap.String("provider", provider), zap.Error(err))
+		return "", fmt.Errorf("get user info: %w", err)
 	}
 
-	user, err := s.userRepo.GetByGoogleID(ctx, userInfo.ID)
+	redirectURL, err := url.Parse(s.frontedCallbackURL)
+	if err != nil {
+		s.log.Error("failed to parse redirect front url", zap.Error(err))
+		return "", fmt.Errorf("parse redirect front url: %w", err)
+	}
+	params := url.Values{}
+
+	if data.LinkUserID != nil {
+		// браузер, вернувшийся с провайдера, может принадлежать не тому, кто начал привязку,
+		// поэтому привязка ждёт ConfirmLink с токеном пользователя LinkUserID
+		linkCode, err := generateState()
+		if err != nil {
+			s.log.Error("failed to generate link code", zap.Error(err))
+			return "", fmt.Errorf("generate link code: %w", err)
+		}
+		pending := model.PendingLink{
+			UserID:   *data.LinkUserID,
+			Provider: profile.Provider,
+			Subject:  profile.Subject,
+			Email:    profile.Email,
+		}
+		if err := s.linkCodeRepo.Save(ctx, linkCode, pending, authCodeTTL); err != nil {
+			s.log.Error("failed to save link code", zap.Error(err))
+			return "", fmt.Errorf("save link code: %w", err)
+		}
+		params.Set("link_code", linkCode)
+		params.Set("provider", provider)
+		redirectURL.RawQuery = params.Encode()
+		return redirectURL.String(), nil
+	}
+
+	user, err := s.userRepo.GetByIdentity(ctx, provider, profile.Subject)
 	if err != nil {
 		if !errors.Is(err, repository.UserNotFound) {
-			s.log.Error("failed to get user by google id", zap.Error(err))
-			return "", fmt.Errorf("get user by google id: %w", err)
+			s.log.Error("failed to get user by identity", zap.Error(err))
+			return "", fmt.Errorf("get user by identity: %w", err)
 		}
-		user, err = s.createUserFromGoogle(ctx, userInfo)
+		user, err = s.createUser(ctx, profile)
 		if err != nil {
-			s.log.Error("failed to create user", zap.Error(err))
-			return "", fmt.Errorf("create user: %w", err)
+			return "", err
 		}
 	}
+	if !user.IsActive && !user.PendingDeletion() {
+		return "", ErrAccountDisabled
+	}
 
 	if err = s.userRepo.UpdateLastLogin(ctx, user.ID); err != nil {
 		s.log.Error("failed to update last login", zap.String("user_id", user.ID.String()), zap.Error(err))
 		return "", fmt.Errorf("update last login: %w", err)
 	}
 
-	tokenPair, err := s.generateTokenPair(user)
+	// токены не попадают в URL: фронтенд один раз меняет код на них через ExchangeCode
+	authCode, err := generateState()
 	if err != nil {
-		s.log.Error("failed to generate token pair", zap.Error(err))
-		return "", fmt.Errorf("generate token pair: %w", err)
+		s.log.Error("failed to generate auth code", zap.Error(err))
+		return "", fmt.Errorf("generate auth code: %w", err)
 	}
-
-	redirectURL, err := url.Parse(s.frontedCallbackURL)
+	err = s.authCodeRepo.Save(ctx, authCode, model.AuthCode{UserID: user.ID, CodeChallenge: data.CodeChallenge}, authCodeTTL)
 	if err != nil {
-		s.log.Error("failed to parse redirect front url", zap.Error(err))
-		return "", fmt.Errorf("parse redirect front url: %w", err)
+		s.log.Error("failed to save auth code", zap.Error(err))
+		return "", fmt.Errorf("save auth code: %w", err)
+	}
+
+	params.Set("code", authCode)
+	if user.PendingDeletion() {
+		// фронтенд предлагает восстановить аккаунт и обменивает код с restore=true
+		params.Set("delete_after", user.DeleteAfter.UTC().Format(time.RFC3339))
 	}
-	params := url.Values{}
-	params.Set("access_token", tokenPair.AccessToken)
-	params.Set("refresh_token", tokenPair.RefreshToken)
 	redirectURL.RawQuery = params.Encode()
 
 	return redirectURL.String(), nil
 }
 
-func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*model.TokenPair, error) {
-	token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.jwtSecret, nil
+// ListIdentities возвращает привязанных к пользователю провайдеров
+func (s *AuthService) ListIdentities(ctx context.Context, userID uuid.UUID) ([]model.UserIdentity, error) {
+	identities, err := s.identityRepo.ListByUser(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to list identities", zap.Error(err))
+	}
+	return identities, err
+}
+
+// UnlinkIdentity отвязывает провайдера; последнюю привязку снять нельзя, иначе войти будет нечем
+func (s *AuthService) UnlinkIdentity(ctx context.Context, userID uuid.UUID, provider string) error {
+	identities, err := s.identityRepo.ListByUser(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to list identities", zap.Error(err))
+		return err
+	}
+	if !slices.ContainsFunc(identities, func(i model.UserIdentity) bool { return i.Provider == provider }) {
+		return ErrIdentityNotFound
+	}
+	if len(identities) == 1 {
+		return ErrLastIdentity
+	}
+
+	err = s.identityRepo.Delete(ctx, userID, provider)
+	if errors.Is(err, repository.IdentityNotFound) {
+		return ErrIdentityNotFound
+	}
+	if err != nil {
+		s.log.Error("failed to delete identity", zap.Error(err))
+	}
+	return err
+}
+
+// ConfirmLink привязывает провайдера по коду из редиректа; код должен быть выдан
+// в потоке привязки, начатом этим же пользователем
+func (s *AuthService) ConfirmLink(ctx context.Context, userID uuid.UUID, code string) (string, error) {
+	pending, err := s.linkCodeRepo.Take(ctx, code)
+	if err != nil {
+		s.log.Error("failed to take link code", zap.Error(err))
+		return "", err
+	}
+	if pending == nil || pending.UserID != userID {
+		return "", ErrInvalidLinkCode
+	}
+
+	profile := &model.OAuthProfile{Provider: pending.Provider, Subject: pending.Subject, Email: pending.Email}
+	if err := s.linkIdentity(ctx, userID, profile); err != nil {
+		return "", err
+	}
+	return pending.Provider, nil
+}
+
+func (s *AuthService) linkIdentity(ctx context.Context, userID uuid.UUID, profile *model.OAuthProfile) error {
+	existing, err := s.identityRepo.Get(ctx, profile.Provider, profile.Subject)
+	switch {
+	case err == nil && existing.UserID == userID:
+		return nil
+	case err == nil:
+		return ErrIdentityInUse
+	case !errors.Is(err, repository.IdentityNotFound):
+		s.log.Error("failed to get identity", zap.Error(err))
+		return err
+	}
+
+	err = s.identityRepo.Add(ctx, &model.UserIdentity{
+		Provider: profile.Provider,
+		Subject:  profile.Subject,
+		UserID:   userID,
+		Email:    profile.Email,
 	})
+	if errors.Is(err, repository.IdentityExists) {
+		// аккаунт провайдера свободен, значит у пользователя уже есть другой аккаунт этого провайдера
+		return ErrProviderLinked
+	}
+	if err != nil {
+		s.log.Error("failed to add identity", zap.Error(err))
+	}
+	return err
+}
+
+// ExchangeCode меняет одноразовый код из редиректа на пару токенов новой сессии.
+// Аккаунт, ожидающий удаления, восстанавливается только при restore.
+func (s *AuthService) ExchangeCode(ctx context.Context, code, codeVerifier string, restore bool, client model.ClientInfo) (*model.TokenPair, error) {
+	data, err := s.authCodeRepo.Take(ctx, code)
+	if err != nil {
+		s.log.Error("failed to take auth code", zap.Error(err))
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrInvalidAuthCode
+	}
+	if data.CodeChallenge != "" && !verifyCodeChallenge(data.CodeChallenge, codeVerifier) {
+		s.log.Warn("pkce verification failed", zap.String("user_id", data.UserID.String()))
+		return nil, ErrInvalidAuthCode
+	}
+
+	user, err := s.userRepo.GetByID(ctx, data.UserID)
+	if err != nil {
+		if errors.Is(err, repository.UserNotFound) {
+			return nil, ErrInvalidAuthCode
+		}
+		s.log.Error("failed to get user", zap.Error(err))
+		return nil, err
+	}
+	if user.PendingDeletion() {
+		if !restore {
+			return nil, ErrAccountPendingDeletion
+		}
+		if user, err = s.restoreAccount(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
+
+	return s.startSession(ctx, user, client)
+}
+
+func (s *AuthService) restoreAccount(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	user, err := s.userRepo.CancelDeletion(ctx, userID)
+	if errors.Is(err, repository.UserNotFound) {
+		// срок восстановления истёк между входом и обменом кода
+		return nil, ErrAccountDisabled
+	}
+	if err != nil {
+		s.log.Error("failed to restore account", zap.Error(err))
+		return nil, err
+	}
+
+	s.log.Info("account restored", zap.String("user_id", userID.String()))
+	return user, nil
+}
+
+// RefreshToken обновляет пару токенов в рамках сессии refresh-токена. Refresh одноразовый:
+// повторное предъявление уже обменянного токена отзывает всю сессию.
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string, client model.ClientInfo) (*model.TokenPair, error) {
+	token, err := s.keys.parse(refreshToken)
 
 	if err != nil {
 		s.log.Error("failed to parse refresh token", zap.Error(err))
@@ -189,6 +416,9 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*m
 		s.log.Warn("user not found")
 		return nil, err
 	}
+	if !user.IsActive {
+		return nil, ErrAccountDisabled
+	}
 
 	if int(tokenVersion) != user.TokenVersion {
 		err = errors.New("token has been invalidated")
@@ -196,17 +426,39 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (*m
 		return nil, err
 	}
 
-	tokenPair, err := s.generateTokenPair(user)
+	sessionID, err := uuidClaim(claims, "sid")
 	if err != nil {
-		err = fmt.Errorf("failed to generate tokens: %w", err)
-		s.log.Error(err.Error())
+		s.log.Error("failed to parse refresh token", zap.Error(err))
+		return nil, err
+	}
+	jti, err := uuidClaim(claims, "jti")
+	if err != nil {
+		s.log.Error("failed to parse refresh token", zap.Error(err))
 		return nil, err
 	}
 
-	return tokenPair, nil
+	return s.rotateSession(ctx, user, sessionID, jti, client)
+}
+
+// Logout завершает сессию, которой принадлежит access token; остальные устройства остаются в системе
+func (s *AuthService) Logout(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if sessionID == uuid.Nil {
+		return s.LogoutAll(ctx, userID)
+	}
+	err := s.revokeSession(ctx, userID, sessionID)
+	if err != nil && !errors.Is(err, repository.SessionNotFound) {
+		s.log.Error("failed to revoke session", zap.Error(err))
+		return err
+	}
+	return nil
 }
 
-func (s *AuthService) Logout(ctx context.Context, userID uuid.UUID) error {
+// LogoutAll отзывает все сессии и делает недействительными уже выданные access token
+func (s *AuthService) LogoutAll(ctx context.Context, userID uuid.UUID) error {
+	if err := s.sessionRepo.RevokeAll(ctx, userID); err != nil {
+		s.log.Error("failed to revoke sessions", zap.Error(err))
+		return err
+	}
 	err := s.userRepo.IncrementTokenVersion(ctx, userID)
 	if err != nil {
 		s.log.Error("failed to increment token version", zap.Error(err))
@@ -214,18 +466,18 @@ func (s *AuthService) Logout(ctx context.Context, userID uuid.UUID) error {
 	return err
 }
 
-// ValidateAccessToken проверяет JWT access token и возвращает user ID и token version
-func (s *AuthService) ValidateAccessToken(ctx context.Context, tokenStr string) (uuid.UUID, int, error) {
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (any, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return s.jwtSecret, nil
-	})
+// JWKS — публичные ключи для проверки наших токенов другими сервисами
+func (s *AuthService) JWKS() model.JWKS {
+	return s.keys.JWKS()
+}
+
+// ValidateAccessToken проверяет JWT access token и возвращает user ID, token version и сессию
+func (s *AuthService) ValidateAccessToken(ctx context.Context, tokenStr string) (*model.AccessClaims, error) {
+	token, err := s.keys.parse(tokenStr)
 
 	if err != nil {
 		s.log.Error("failed to parse access token", zap.Error(err))
-		return uuid.Nil, 0, err
+		return nil, err
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
@@ -233,58 +485,69 @@ func (s *AuthService) ValidateAccessToken(ctx context.Context, tokenStr string)
 		if !ok || tokenType != "access" {
 			err = errors.New("invalid token type")
 			s.log.Error("invalid token", zap.Error(err))
-			return uuid.Nil, 0, err
+			return nil, err
 		}
 
 		userIDStr, ok := claims["user_id"].(string)
 		if !ok {
 			err = errors.New("invalid user id in token")
 			s.log.Error("invalid token", zap.Error(err))
-			return uuid.Nil, 0, err
+			return nil, err
 		}
 
 		userID, err := uuid.Parse(userIDStr)
 		if err != nil {
 			err = fmt.Errorf("invalid user id format: %w", err)
 			s.log.Error("invalid token", zap.Error(err))
-			return uuid.Nil, 0, err
+			return nil, err
 		}
 
 		tokenVersion, ok := claims["token_version"].(float64)
 		if !ok {
 			err = errors.New("invalid token version in token")
 			s.log.Error("invalid token", zap.Error(err))
-			return uuid.Nil, 0, err
+			return nil, err
 		}
 
-		return userID, int(tokenVersion), nil
+		// sid и role отсутствуют у токенов, выданных до появления сессий и ролей
+		sessionID, _ := uuidClaim(claims, "sid")
+		role, _ := claims["role"].(string)
+
+		return &model.AccessClaims{
+			UserID:       userID,
+			TokenVersion: int(tokenVersion),
+			SessionID:    sessionID,
+			Role:         model.Role(role),
+		}, nil
 	}
 
 	s.log.Error("access token invalid")
-	return uuid.Nil, 0, errors.New("invalid token")
+	return nil, errors.New("invalid token")
 }
 
-func (s *AuthService) generateTokenPair(user *model.User) (*model.TokenPair, error) {
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+func (s *AuthService) generateTokenPair(user *model.User, session *model.Session) (*model.TokenPair, error) {
+	accessTokenStr, err := s.keys.sign(jwt.MapClaims{
 		"type":          "access",
 		"user_id":       user.ID.String(),
 		"token_version": user.TokenVersion,
+		"role":          string(user.Role),
+		"sid":           session.ID.String(),
 		"exp":           time.Now().Add(s.accessTTl).Unix(),
 		"iat":           time.Now().Unix(),
 	})
-	accessTokenStr, err := accessToken.SignedString(s.jwtSecret)
 	if err != nil {
 		return nil, fmt.Errorf("failed to signed token: %w", err)
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	refreshTokenStr, err := s.keys.sign(jwt.MapClaims{
 		"type":          "refresh",
 		"user_id":       user.ID.String(),
 		"token_version": user.TokenVersion,
+		"sid":           session.ID.String(),
+		"jti":           session.RefreshJTI.String(),
 		"exp":           time.Now().Add(s.refreshTTL).Unix(),
 		"iat":           time.Now().Unix(),
 	})
-	refreshTokenStr, err := refreshToken.SignedString(s.jwtSecret)
 	if err != nil {
 		return nil, fmt.Errorf("failed to signed token: %w", err)
 	}
@@ -296,62 +559,53 @@ func (s *AuthService) generateTokenPair(user *model.User) (*model.TokenPair, err
 	}, nil
 }
 
-func (s *AuthService) createUserFromGoogle(ctx context.Context, googleInfo *model.GoogleUserInfo) (*model.User, error) {
-	if googleInfo == nil {
-		return nil, errors.New("google info is nil")
+// createUser регистрирует пользователя при первом входе через провайдера.
+// Аккаунт с тем же email не объединяется автоматически: провайдера нужно привязать из него.
+func (s *AuthService) createUser(ctx context.Context, profile *model.OAuthProfile) (*model.User, error) {
+	if profile.Email == "" || !profile.EmailVerified {
+		return nil, ErrEmailNotVerified
 	}
 
 	user := &model.User{
 		ID:           uuid.New(),
-		Email:        googleInfo.Email,
-		Name:         googleInfo.Name,
-		Picture:      googleInfo.Picture,
-		GoogleID:     googleInfo.ID,
+		Email:        profile.Email,
+		Name:         profile.Name,
+		Picture:      profile.Picture,
+		Role:         model.RoleStudent,
 		TokenVersion: 0,
 		IsActive:     true,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
-
-	if err := s.userRepo.Create(ctx, user); err != nil {
-		return nil, err
-	}
-
-	return user, nil
-}
-
-func (s *AuthService) getGoogleUserInfo(ctx context.Context, token string) (*model.GoogleUserInfo, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.userInfoURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if slices.Contains(s.adminEmails, strings.ToLower(profile.Email)) {
+		user.Role = model.RoleAdmin
 	}
-
-	q := req.URL.Query()
-	q.Set("access_token", token)
-	req.URL.RawQuery = q.Encode()
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+	identity := &model.UserIdentity{
+		Provider: profile.Provider,
+		Subject:  profile.Subject,
+		Email:    profile.Email,
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get user info from Google and status code equal %d", resp.StatusCode)
+	err := s.userRepo.Create(ctx, user, identity)
+	if errors.Is(err, repository.UserEmailTaken) {
+		return nil, ErrEmailInUse
 	}
-
-	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read body: %w", err)
+		s.log.Error("failed to create user", zap.Error(err))
+		return nil, fmt.Errorf("create user: %w", err)
 	}
 
-	var userInfo model.GoogleUserInfo
-	err = json.Unmarshal(body, &userInfo)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal userInfo: %w", err)
-	}
+	return user, nil
+}
 
-	return &userInfo, nil
+// verifyCodeChallenge проверяет PKCE S256: challenge = base64url(sha256(verifier)) без паддинга
+func verifyCodeChallenge(challenge, verifier string) bool {
+	if verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
 }
 
 func generateState() (string, error) {
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/config"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// rsaMinBits — минимальный размер RSA-ключа для подписи токенов
+const rsaMinBits = 2048
+
+type verifyKey struct {
+	method jwt.SigningMethod
+	public crypto.PublicKey
+}
+
+// JWTKeys — ключ подписи и набор ключей проверки, выбираемых по kid.
+// Без JWT_SIGNING_KEY_FILE токены подписываются HS256 с JWT_SECRET, как раньше.
+type JWTKeys struct {
+	signKID    string
+	signMethod jwt.SigningMethod
+	signKey    any
+	verify     map[string]verifyKey
+	secret     []byte
+}
+
+// LoadJWTKeys читает PEM-ключи из файлов. Публичная часть ключа подписи
+// всегда входит в набор проверки; JWT_VERIFY_KEY_FILES добавляет прежние ключи,
+// чтобы выданные ими токены дожили до истечения после ротации.
+func LoadJWTKeys(cfg config.JWTConfig) (*JWTKeys, error) {
+	keys := &JWTKeys{verify: map[string]verifyKey{}}
+	if cfg.SigningKeyFile == "" {
+		if cfg.Secret == "" {
+			return nil, errors.New("neither JWT_SIGNING_KEY_FILE nor JWT_SECRET is set")
+		}
+		keys.signMethod = jwt.SigningMethodHS256
+		keys.signKey = []byte(cfg.Secret)
+		keys.secret = []byte(cfg.Secret)
+		return keys, nil
+	}
+
+	signKey, err := readPrivateKey(cfg.SigningKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("JWT_SIGNING_KEY_FILE: %w", err)
+	}
+	kid, key, err := keys.add(signKey.Public())
+	if err != nil {
+		return nil, fmt.Errorf("JWT_SIGNING_KEY_FILE: %w", err)
+	}
+	keys.signKID = kid
+	keys.signMethod = key.method
+	keys.signKey = signKey
+
+	for _, path := range cfg.VerifyKeyFiles {
+		public, err := readPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("JWT_VERIFY_KEY_FILES %s: %w", path, err)
+		}
+		if _, _, err := keys.add(public); err != nil {
+			return nil, fmt.Errorf("JWT_VERIFY_KEY_FILES %s: %w", path, err)
+		}
+	}
+
+	return keys, nil
+}
+
+func (k *JWTKeys) add(public crypto.PublicKey) (string, verifyKey, error) {
+	var key verifyKey
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < rsaMinBits {
+			return "", key, fmt.Errorf("RSA key must be at least %d bits", rsaMinBits)
+		}
+		key = verifyKey{method: jwt.SigningMethodRS256, public: pub}
+	case ed25519.PublicKey:
+		key = verifyKey{method: jwt.SigningMethodEdDSA, public: pub}
+	default:
+		return "", key, fmt.Errorf("unsupported key type %T, expected RSA or Ed25519", public)
+	}
+
+	kid := keyThumbprint(jwkOf(public))
+	k.verify[kid] = key
+	return kid, key, nil
+}
+
+// sign подписывает claims текущим ключом и проставляет kid в заголовок
+func (k *JWTKeys) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signMethod, claims)
+	if k.signKID != "" {
+		token.Header["kid"] = k.signKID
+	}
+	return token.SignedString(k.signKey)
+}
+
+// parse проверяет подпись ключом из kid; алгоритм берётся из ключа, а не из заголовка
+func (k *JWTKeys) parse(tokenStr string) (*jwt.Token, error) {
+	return jwt.Parse(tokenStr, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			if k.secret == nil {
+				return nil, errors.New("token has no kid")
+			}
+			if token.Method != jwt.SigningMethodHS256 {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return k.secret, nil
+		}
+
+		key, ok := k.verify[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown kid %q", kid)
+		}
+		if token.Method != key.method {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.public, nil
+	})
+}
+
+// JWKS — публичные ключи проверки для /.well-known/jwks.json; при HS256 набор пуст
+func (k *JWTKeys) JWKS() model.JWKS {
+	set := model.JWKS{Keys: []model.JWK{}}
+	for kid, key := range k.verify {
+		jwk := jwkOf(key.public)
+		jwk.Kid = kid
+		jwk.Use = "sig"
+		jwk.Alg = key.method.Alg()
+		set.Keys = append(set.Keys, jwk)
+	}
+	slices.SortFunc(set.Keys, func(a, b model.JWK) int { return strings.Compare(a.Kid, b.Kid) })
+	return set
+}
+
+func jwkOf(public crypto.PublicKey) model.JWK {
+	enc := base64.RawURLEncoding.EncodeToString
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		return model.JWK{Kty: "RSA", N: enc(pub.N.Bytes()), E: enc(big.NewInt(int64(pub.E)).Bytes())}
+	case ed25519.PublicKey:
+		return model.JWK{Kty: "OKP", Crv: "Ed25519", X: enc(pub)}
+	}
+	return model.JWK{}
+}
+
+// keyThumbprint — kid по RFC 7638: SHA-256 от обязательных полей JWK в лексикографическом порядке
+func keyThumbprint(jwk model.JWK) string {
+	var members any
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	return block, nil
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("unexpected PEM block %q, expected a private key", block.Type)
+}
+
+// readPublicKey принимает и публичный, и приватный ключ: после ротации
+// прежний ключ подписи можно просто перенести в JWT_VERIFY_KEY_FILES
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	signer, err := readPrivateKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Public(), nil
+}
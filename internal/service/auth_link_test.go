@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/config"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/oauth"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type fakeStateRepo struct {
+	states map[string]model.OAuthState
+}
+
+func (r *fakeStateRepo) Save(_ context.Context, state string, data model.OAuthState, _ time.Duration) error {
+	r.states[state] = data
+	return nil
+}
+
+func (r *fakeStateRepo) Take(_ context.Context, state string) (*model.OAuthState, error) {
+	data, ok := r.states[state]
+	if !ok {
+		return nil, nil
+	}
+	delete(r.states, state)
+	return &data, nil
+}
+
+type fakeLinkCodeRepo struct {
+	codes map[string]model.PendingLink
+}
+
+func (r *fakeLinkCodeRepo) Save(_ context.Context, code string, data model.PendingLink, _ time.Duration) error {
+	r.codes[code] = data
+	return nil
+}
+
+func (r *fakeLinkCodeRepo) Take(_ context.Context, code string) (*model.PendingLink, error) {
+	data, ok := r.codes[code]
+	if !ok {
+		return nil, nil
+	}
+	delete(r.codes, code)
+	return &data, nil
+}
+
+type fakeIdentityRepo struct {
+	repository.IdentityRepository
+	identities []model.UserIdentity
+}
+
+func (r *fakeIdentityRepo) Get(_ context.Context, provider, subject string) (*model.UserIdentity, error) {
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			return &i, nil
+		}
+	}
+	return nil, repository.IdentityNotFound
+}
+
+func (r *fakeIdentityRepo) Add(_ context.Context, identity *model.UserIdentity) error {
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+// newFakeGitHub поднимает сервер с token endpoint и API GitHub, отдающий одного пользователя
+func newFakeGitHub(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != "provider-code" {
+			http.Error(w, "bad code", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "gh-token", "token_type": "bearer"})
+	})
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(model.GitHubUserInfo{ID: 42, Login: "octocat"})
+	})
+	mux.HandleFunc("GET /user/emails", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]model.GitHubEmail{{Email: "octo@example.com", Primary: true, Verified: true}})
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLinkFlowRequiresInitiatingUser(t *testing.T) {
+	gh := newFakeGitHub(t)
+	owner := uuid.New()
+
+	tests := []struct {
+		name      string
+		confirmer uuid.UUID
+		wantErr   error
+		wantLinks int
+	}{
+		{name: "initiating user", confirmer: owner, wantLinks: 1},
+		{name: "other user", confirmer: uuid.New(), wantErr: ErrInvalidLinkCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			identities := &fakeIdentityRepo{}
+			provider := oauth.NewGitHub(config.GitHubOAuthConfig{
+				ClientID:    "client",
+				CallbackURL: "http://api.test/api/auth/github/callback",
+				AuthURL:     gh.URL + "/login/oauth/authorize",
+				TokenURL:    gh.URL + "/login/oauth/access_token",
+				APIURL:      gh.URL,
+			})
+			s := NewAuthService(
+				zap.NewNop(), nil, identities,
+				&fakeStateRepo{states: map[string]model.OAuthState{}}, nil,
+				&fakeLinkCodeRepo{codes: map[string]model.PendingLink{}},
+				nil, nil,
+				[]oauth.Provider{provider}, "http://front.test/callback", nil, config.JWTConfig{}, nil,
+			)
+
+			authURL, err := s.GetLinkURL(ctx, owner, "github")
+			if err != nil {
+				t.Fatalf("GetLinkURL: %v", err)
+			}
+			parsed, err := url.Parse(authURL)
+			if err != nil {
+				t.Fatalf("parse auth url: %v", err)
+			}
+
+			redirect, err := s.HandleCallback(ctx, "github", "provider-code", parsed.Query().Get("state"))
+			if err != nil {
+				t.Fatalf("HandleCallback: %v", err)
+			}
+			if len(identities.identities) != 0 {
+				t.Fatal("identity linked before confirmation")
+			}
+			parsed, err = url.Parse(redirect)
+			if err != nil {
+				t.Fatalf("parse redirect: %v", err)
+			}
+			code := parsed.Query().Get("link_code")
+			if code == "" || parsed.Query().Get("code") != "" {
+				t.Fatalf("redirect %s: want link_code and no auth code", redirect)
+			}
+
+			linked, err := s.ConfirmLink(ctx, tt.confirmer, code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ConfirmLink error = %v, want %v", err, tt.wantErr)
+			}
+			if len(identities.identities) != tt.wantLinks {
+				t.Fatalf("linked identities = %d, want %d", len(identities.identities), tt.wantLinks)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			got := identities.identities[0]
+			if linked != "github" || got.UserID != owner || got.Subject != "42" || got.Email != "octo@example.com" {
+				t.Fatalf("linked %q identity %+v", linked, got)
+			}
+			if _, err := s.ConfirmLink(ctx, owner, code); !errors.Is(err, ErrInvalidLinkCode) {
+				t.Fatalf("second ConfirmLink error = %v, want ErrInvalidLinkCode", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrRefreshReused — предъявлен уже обменянный refresh token; сессия отозвана
+	ErrRefreshReused = errors.New("refresh token reuse detected")
+)
+
+// ListSessions возвращает активные сессии; current — сессия текущего access token
+func (s *AuthService) ListSessions(ctx context.Context, userID, current uuid.UUID) ([]model.Session, error) {
+	sessions, err := s.sessionRepo.ListActive(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to list sessions", zap.Error(err))
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == current
+	}
+	return sessions, nil
+}
+
+// RevokeSession завершает сессию устройства: её refresh token больше не обменивается,
+// а выданные в ней access token отклоняются middleware
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	err := s.revokeSession(ctx, userID, sessionID)
+	if errors.Is(err, repository.SessionNotFound) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		s.log.Error("failed to revoke session", zap.Error(err))
+	}
+	return err
+}
+
+// SessionRevoked — отозвана ли сессия access token; токены без сессии не проверяются
+func (s *AuthService) SessionRevoked(ctx context.Context, sessionID uuid.UUID) (bool, error) {
+	if sessionID == uuid.Nil {
+		return false, nil
+	}
+	revoked, err := s.revokedRepo.Exists(ctx, sessionID)
+	if err != nil {
+		s.log.Error("failed to check revoked session", zap.Error(err))
+	}
+	return revoked, err
+}
+
+// revokeSession отзывает сессию в Postgres и запоминает её в Redis на время жизни access token
+func (s *AuthService) revokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if err := s.sessionRepo.Revoke(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	return s.revokedRepo.Add(ctx, sessionID, s.accessTTl)
+}
+
+func (s *AuthService) startSession(ctx context.Context, user *model.User, client model.ClientInfo) (*model.TokenPair, error) {
+	session := &model.Session{
+		UserID:     user.ID,
+		RefreshJTI: uuid.New(),
+		Device:     deviceName(client.UserAgent),
+		IP:         client.IP,
+		UserAgent:  client.UserAgent,
+		ExpiresAt:  time.Now().Add(s.refreshTTL),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		s.log.Error("failed to create session", zap.Error(err))
+		return nil, fmt.Errorf("create session: %w", err)
+	}
+
+	tokenPair, err := s.generateTokenPair(user, session)
+	if err != nil {
+		s.log.Error("failed to generate token pair", zap.Error(err))
+		return nil, fmt.Errorf("generate token pair: %w", err)
+	}
+	return tokenPair, nil
+}
+
+// rotateSession выдаёт новую пару, если jti — последний выданный в сессии.
+// Иначе токен уже обменивался (украден или отправлен дважды), и сессия отзывается целиком.
+func (s *AuthService) rotateSession(ctx context.Context, user *model.User, sessionID, jti uuid.UUID, client model.ClientInfo) (*model.TokenPair, error) {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.SessionNotFound) {
+			return nil, ErrSessionNotFound
+		}
+		s.log.Error("failed to get session", zap.Error(err))
+		return nil, err
+	}
+	if session.UserID != user.ID || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+
+	session.RefreshJTI = uuid.New()
+	session.Device = deviceName(client.UserAgent)
+	session.IP = client.IP
+	session.UserAgent = client.UserAgent
+	session.ExpiresAt = time.Now().Add(s.refreshTTL)
+
+	err = s.sessionRepo.Rotate(ctx, session, jti)
+	if errors.Is(err, repository.SessionNotFound) {
+		s.log.Warn("refresh token reuse detected, revoking session",
+			zap.String("user_id", user.ID.String()), zap.String("session_id", sessionID.String()))
+		if err := s.revokeSession(ctx, user.ID, sessionID); err != nil && !errors.Is(err, repository.SessionNotFound) {
+			s.log.Error("failed to revoke session", zap.Error(err))
+		}
+		return nil, ErrRefreshReused
+	}
+	if err != nil {
+		s.log.Error("failed to rotate session", zap.Error(err))
+		return nil, err
+	}
+
+	tokenPair, err := s.generateTokenPair(user, session)
+	if err != nil {
+		s.log.Error("failed to generate token pair", zap.Error(err))
+		return nil, fmt.Errorf("generate token pair: %w", err)
+	}
+	return tokenPair, nil
+}
+
+func uuidClaim(claims jwt.MapClaims, name string) (uuid.UUID, error) {
+	value, ok := claims[name].(string)
+	if !ok {
+		return uuid.Nil, fmt.Errorf("missing %s in token", name)
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid %s format: %w", name, err)
+	}
+	return id, nil
+}
+
+// deviceName делает из User-Agent подпись вида "Firefox on Linux" для списка сессий
+func deviceName(userAgent string) string {
+	browsers := []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"}, {"Safari/", "Safari"}, {"curl/", "curl"},
+	}
+	systems := []struct{ token, name string }{
+		{"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"},
+		{"Windows", "Windows"}, {"Mac OS X", "macOS"}, {"Linux", "Linux"},
+	}
+
+	var browser, system string
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, sys := range systems {
+		if strings.Contains(userAgent, sys.token) {
+			system = sys.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+	return "Unknown device"
+}
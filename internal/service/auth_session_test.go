@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/config"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type fakeUserRepo struct {
+	repository.UserRepository
+	users map[uuid.UUID]model.User
+}
+
+func (r *fakeUserRepo) GetByID(_ context.Context, id uuid.UUID) (*model.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repository.UserNotFound
+	}
+	return &user, nil
+}
+
+type fakeAuthCodeRepo struct {
+	codes map[string]model.AuthCode
+}
+
+func (r *fakeAuthCodeRepo) Save(_ context.Context, code string, data model.AuthCode, _ time.Duration) error {
+	r.codes[code] = data
+	return nil
+}
+
+func (r *fakeAuthCodeRepo) Take(_ context.Context, code string) (*model.AuthCode, error) {
+	data, ok := r.codes[code]
+	if !ok {
+		return nil, nil
+	}
+	delete(r.codes, code)
+	return &data, nil
+}
+
+type fakeSessionRepo struct {
+	repository.SessionRepository
+	sessions map[uuid.UUID]model.Session
+}
+
+func (r *fakeSessionRepo) Create(_ context.Context, s *model.Session) error {
+	s.ID = uuid.New()
+	r.sessions[s.ID] = *s
+	return nil
+}
+
+func (r *fakeSessionRepo) GetByID(_ context.Context, id uuid.UUID) (*model.Session, error) {
+	s, ok := r.sessions[id]
+	if !ok {
+		return nil, repository.SessionNotFound
+	}
+	return &s, nil
+}
+
+func (r *fakeSessionRepo) Rotate(_ context.Context, s *model.Session, oldJTI uuid.UUID) error {
+	current, ok := r.sessions[s.ID]
+	if !ok || current.RevokedAt != nil || current.RefreshJTI != oldJTI {
+		return repository.SessionNotFound
+	}
+	r.sessions[s.ID] = *s
+	return nil
+}
+
+func (r *fakeSessionRepo) Revoke(_ context.Context, userID, id uuid.UUID) error {
+	s, ok := r.sessions[id]
+	if !ok || s.UserID != userID || s.RevokedAt != nil {
+		return repository.SessionNotFound
+	}
+	now := time.Now()
+	s.RevokedAt = &now
+	r.sessions[id] = s
+	return nil
+}
+
+type fakeRevokedRepo struct {
+	revoked map[uuid.UUID]bool
+}
+
+func (r *fakeRevokedRepo) Add(_ context.Context, sessionID uuid.UUID, _ time.Duration) error {
+	r.revoked[sessionID] = true
+	return nil
+}
+
+func (r *fakeRevokedRepo) Exists(_ context.Context, sessionID uuid.UUID) (bool, error) {
+	return r.revoked[sessionID], nil
+}
+
+type sessionTestEnv struct {
+	service  *AuthService
+	user     model.User
+	codes    *fakeAuthCodeRepo
+	sessions *fakeSessionRepo
+	revoked  *fakeRevokedRepo
+}
+
+func newSessionTestEnv(t *testing.T) *sessionTestEnv {
+	t.Helper()
+
+	jwtCfg := config.JWTConfig{Secret: "test-secret", AccessTTL: time.Minute, RefreshTTL: time.Hour}
+	keys, err := LoadJWTKeys(jwtCfg)
+	if err != nil {
+		t.Fatalf("LoadJWTKeys: %v", err)
+	}
+
+	env := &sessionTestEnv{
+		user:     model.User{ID: uuid.New(), Role: model.RoleStudent, IsActive: true},
+		codes:    &fakeAuthCodeRepo{codes: map[string]model.AuthCode{}},
+		sessions: &fakeSessionRepo{sessions: map[uuid.UUID]model.Session{}},
+		revoked:  &fakeRevokedRepo{revoked: map[uuid.UUID]bool{}},
+	}
+	users := &fakeUserRepo{users: map[uuid.UUID]model.User{env.user.ID: env.user}}
+	env.service = NewAuthService(
+		zap.NewNop(), users, nil, nil, env.codes, nil, env.sessions, env.revoked,
+		nil, "http://front.test/callback", keys, jwtCfg, nil,
+	)
+	return env
+}
+
+func TestExchangeCodeVerifiesPKCE(t *testing.T) {
+	// пример из RFC 7636, приложение B
+	const (
+		verifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	)
+
+	tests := []struct {
+		name     string
+		verifier string
+		wantErr  error
+	}{
+		{name: "matching verifier", verifier: verifier},
+		{name: "wrong verifier", verifier: verifier + "x", wantErr: ErrInvalidAuthCode},
+		{name: "missing verifier", verifier: "", wantErr: ErrInvalidAuthCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newSessionTestEnv(t)
+			env.codes.codes["code"] = model.AuthCode{UserID: env.user.ID, CodeChallenge: challenge}
+
+			pair, err := env.service.ExchangeCode(context.Background(), "code", tt.verifier, false, model.ClientInfo{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ExchangeCode error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && pair == nil {
+				t.Fatal("ExchangeCode returned no tokens")
+			}
+			if _, ok := env.codes.codes["code"]; ok {
+				t.Fatal("auth code is reusable after exchange")
+			}
+		})
+	}
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+	env := newSessionTestEnv(t)
+	env.codes.codes["code"] = model.AuthCode{UserID: env.user.ID}
+
+	first, err := env.service.ExchangeCode(ctx, "code", "", false, model.ClientInfo{})
+	if err != nil {
+		t.Fatalf("ExchangeCode: %v", err)
+	}
+	second, err := env.service.RefreshToken(ctx, first.RefreshToken, model.ClientInfo{})
+	if err != nil {
+		t.Fatalf("first refresh: %v", err)
+	}
+
+	// повторное предъявление обменянного токена — признак кражи
+	if _, err := env.service.RefreshToken(ctx, first.RefreshToken, model.ClientInfo{}); !errors.Is(err, ErrRefreshReused) {
+		t.Fatalf("reused refresh error = %v, want ErrRefreshReused", err)
+	}
+
+	if _, err := env.service.RefreshToken(ctx, second.RefreshToken, model.ClientInfo{}); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("refresh after reuse error = %v, want ErrSessionNotFound", err)
+	}
+
+	claims, err := env.service.ValidateAccessToken(ctx, second.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken: %v", err)
+	}
+	revoked, err := env.service.SessionRevoked(ctx, claims.SessionID)
+	if err != nil || !revoked {
+		t.Fatalf("SessionRevoked = %v, %v; want access tokens of the session rejected", revoked, err)
+	}
+}
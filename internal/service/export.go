@@ -0,0 +1,328 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/config"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// exportBuildTimeout ограничивает сборку архива; на это же время ставится блокировка
+	exportBuildTimeout = 5 * time.Minute
+	// exportMaxSize — предел сжатого архива: он целиком хранится в Redis одним значением
+	exportMaxSize = 32 << 20
+)
+
+var (
+	ErrExportInProgress = errors.New("export already in progress")
+	ErrExportNotFound   = errors.New("export not found")
+	ErrExportTooLarge   = errors.New("export archive is too large")
+)
+
+// ExportService собирает архив со всеми данными пользователя: export.json
+// и файлы решений chapter/task/attempt-N.go. Сборка идёт в фоне, готовый архив
+// лежит в Redis и скачивается по ссылке до истечения linkTTL.
+type ExportService struct {
+	log                *zap.Logger
+	userRepo           repository.UserRepository
+	identityRepo       repository.IdentityRepository
+	sessionRepo        repository.SessionRepository
+	personalTokenRepo  repository.PersonalTokenRepository
+	submissionRepo     repository.SubmissionRepository
+	rejudgeRepo        repository.RejudgeRepository
+	theoryProgressRepo repository.TheoryProgressRepository
+	quizAttemptRepo    repository.QuizAttemptRepository
+	quizReviewRepo     repository.QuizReviewRepository
+	exportRepo         repository.ExportRepository
+	linkTTL            time.Duration
+}
+
+func NewExportService(
+	log *zap.Logger,
+	userRepo repository.UserRepository,
+	identityRepo repository.IdentityRepository,
+	sessionRepo repository.SessionRepository,
+	personalTokenRepo repository.PersonalTokenRepository,
+	submissionRepo repository.SubmissionRepository,
+	rejudgeRepo repository.RejudgeRepository,
+	theoryProgressRepo repository.TheoryProgressRepository,
+	quizAttemptRepo repository.QuizAttemptRepository,
+	quizReviewRepo repository.QuizReviewRepository,
+	exportRepo repository.ExportRepository,
+	exportCfg config.ExportConfig,
+) *ExportService {
+	return &ExportService{
+		log:                log,
+		userRepo:           userRepo,
+		identityRepo:       identityRepo,
+		sessionRepo:        sessionRepo,
+		personalTokenRepo:  personalTokenRepo,
+		submissionRepo:     submissionRepo,
+		rejudgeRepo:        rejudgeRepo,
+		theoryProgressRepo: theoryProgressRepo,
+		quizAttemptRepo:    quizAttemptRepo,
+		quizReviewRepo:     quizReviewRepo,
+		exportRepo:         exportRepo,
+		linkTTL:            exportCfg.LinkTTL,
+	}
+}
+
+// Start ставит сборку архива в фон и сразу возвращает задачу в статусе pending
+func (s *ExportService) Start(ctx context.Context, userID uuid.UUID) (*model.ExportJob, error) {
+	locked, err := s.exportRepo.Lock(ctx, userID, exportBuildTimeout)
+	if err != nil {
+		s.log.Error("failed to lock export", zap.Error(err))
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrExportInProgress
+	}
+
+	job := &model.ExportJob{Status: model.ExportPending, CreatedAt: time.Now()}
+	if err := s.exportRepo.SaveJob(ctx, userID, job, s.linkTTL); err != nil {
+		s.log.Error("failed to save export job", zap.Error(err))
+		s.exportRepo.Unlock(ctx, userID)
+		return nil, err
+	}
+
+	go s.run(userID, *job)
+	return job, nil
+}
+
+func (s *ExportService) Status(ctx context.Context, userID uuid.UUID) (*model.ExportJob, error) {
+	job, err := s.exportRepo.GetJob(ctx, userID)
+	if errors.Is(err, repository.ExportNotFound) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		s.log.Error("failed to get export job", zap.Error(err))
+	}
+	return job, err
+}
+
+// Download отдаёт архив по токену из ссылки; после истечения ссылки — ErrExportNotFound
+func (s *ExportService) Download(ctx context.Context, token string) ([]byte, error) {
+	data, err := s.exportRepo.GetArchive(ctx, token)
+	if errors.Is(err, repository.ExportNotFound) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		s.log.Error("failed to get export archive", zap.Error(err))
+	}
+	return data, err
+}
+
+// run выполняется вне запроса: сборка ограничена exportBuildTimeout,
+// а итог записывается даже после таймаута, чтобы задача не зависла в pending
+func (s *ExportService) run(userID uuid.UUID, job model.ExportJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportBuildTimeout)
+	token, err := s.store(ctx, userID)
+	cancel()
+
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	if err != nil {
+		s.log.Error("failed to build export", zap.String("user_id", userID.String()), zap.Error(err))
+		job.Status = model.ExportFailed
+	} else {
+		expiresAt := finishedAt.Add(s.linkTTL)
+		job.Status = model.ExportReady
+		job.DownloadURL = "/api/users/export/download/" + token
+		job.ExpiresAt = &expiresAt
+	}
+
+	ctx = context.Background()
+	if err := s.exportRepo.SaveJob(ctx, userID, &job, s.linkTTL); err != nil {
+		s.log.Error("failed to save export job", zap.Error(err))
+	}
+	if err := s.exportRepo.Unlock(ctx, userID); err != nil {
+		s.log.Error("failed to unlock export", zap.Error(err))
+	}
+}
+
+// store собирает архив и сохраняет его под случайным токеном ссылки
+func (s *ExportService) store(ctx context.Context, userID uuid.UUID) (string, error) {
+	data, err := s.build(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > exportMaxSize {
+		return "", fmt.Errorf("%w: %d bytes", ErrExportTooLarge, len(data))
+	}
+
+	// аккаунт могли удалить, пока шла сборка; его данные не должны остаться доступными
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("get user: %w", err)
+	}
+	if !user.IsActive {
+		return "", ErrAccountDisabled
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate export token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	if err := s.exportRepo.SaveArchive(ctx, userID, token, data, s.linkTTL); err != nil {
+		return "", fmt.Errorf("save archive: %w", err)
+	}
+	return token, nil
+}
+
+func (s *ExportService) build(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	export, err := s.collect(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for _, sub := range export.Submissions {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: sub.File, Method: zip.Deflate, Modified: sub.CreatedAt})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write([]byte(sub.Code)); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: "export.json", Method: zip.Deflate, Modified: export.ExportedAt})
+	if err != nil {
+		return nil, err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(export); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *ExportService) collect(ctx context.Context, userID uuid.UUID) (*model.UserExport, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	export := &model.UserExport{ExportedAt: time.Now(), Profile: *user}
+
+	if export.Identities, err = s.identityRepo.ListByUser(ctx, userID); err != nil {
+		return nil, fmt.Errorf("list identities: %w", err)
+	}
+	if export.Sessions, err = s.sessionRepo.ListActive(ctx, userID); err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	if export.PersonalTokens, err = s.personalTokenRepo.ListByUser(ctx, userID); err != nil {
+		return nil, fmt.Errorf("list personal tokens: %w", err)
+	}
+	if export.TheoryProgress, err = s.theoryProgressRepo.ListByUser(ctx, userID); err != nil {
+		return nil, fmt.Errorf("list theory progress: %w", err)
+	}
+
+	submissions, err := s.submissionRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list submissions: %w", err)
+	}
+	rejudges, err := s.rejudgeRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list rejudges: %w", err)
+	}
+	export.Submissions = exportSubmissions(submissions, rejudges)
+
+	attempts, err := s.quizAttemptRepo.ListFinished(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list quiz attempts: %w", err)
+	}
+	export.QuizAttempts = make([]model.ExportQuizAttempt, 0, len(attempts))
+	for _, a := range attempts {
+		item := model.ExportQuizAttempt{
+			ID:         a.ID,
+			Score:      a.Score,
+			Correct:    a.Correct,
+			Total:      a.Total,
+			Answers:    make([]model.ExportQuizAnswer, 0, len(a.Answers)),
+			StartedAt:  a.StartedAt,
+			FinishedAt: a.FinishedAt,
+		}
+		for _, ans := range a.Answers {
+			item.Answers = append(item.Answers, model.ExportQuizAnswer(ans))
+		}
+		export.QuizAttempts = append(export.QuizAttempts, item)
+	}
+
+	cards, err := s.quizReviewRepo.List(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list quiz reviews: %w", err)
+	}
+	export.QuizReviews = make([]model.ExportQuizReview, 0, len(cards))
+	for _, c := range cards {
+		export.QuizReviews = append(export.QuizReviews, model.ExportQuizReview{
+			QuestionID:   c.QuestionID,
+			Ease:         c.Ease,
+			IntervalDays: c.IntervalDays,
+			Repetitions:  c.Repetitions,
+			DueAt:        c.DueAt,
+			ReviewedAt:   c.ReviewedAt,
+		})
+	}
+
+	return export, nil
+}
+
+// exportSubmissions нумерует попытки по каждой задаче в хронологическом порядке
+// и прикрепляет перепроверки; посылки приходят отсортированными по задаче и времени
+func exportSubmissions(submissions []model.Submission, rejudges []model.Rejudge) []model.ExportSubmission {
+	bySubmission := make(map[uuid.UUID][]model.Rejudge)
+	for _, rj := range rejudges {
+		bySubmission[rj.SubmissionID] = append(bySubmission[rj.SubmissionID], rj)
+	}
+
+	result := make([]model.ExportSubmission, 0, len(submissions))
+	attempts := make(map[string]int)
+	for _, sub := range submissions {
+		dir := path.Join(exportPathSegment(sub.ChapterSlug), exportPathSegment(sub.TaskSlug))
+		attempts[dir]++
+		result = append(result, model.ExportSubmission{
+			Submission: sub,
+			File:       fmt.Sprintf("%s/attempt-%d.go", dir, attempts[dir]),
+			Rejudges:   bySubmission[sub.ID],
+		})
+	}
+	return result
+}
+
+// exportPathSegment оставляет в slug только безопасные для пути в архиве символы
+func exportPathSegment(slug string) string {
+	segment := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, slug)
+	if segment == "" {
+		return "_"
+	}
+	return segment
+}
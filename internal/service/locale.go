@@ -0,0 +1,36 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+)
+
+// localizedName — имя файла перевода: ("task", ".md", "en") -> "task.en.md".
+// Для языка по умолчанию возвращает исходное имя.
+func localizedName(base, ext, locale string) string {
+	if locale == model.DefaultLocale {
+		return base + ext
+	}
+	return base + "." + locale + ext
+}
+
+// splitLocaleSuffix разбирает "01-basics.en.md" на "01-basics" и "en".
+// Для файлов без суффикса языка возвращает model.DefaultLocale.
+func splitLocaleSuffix(name, ext string) (string, string) {
+	base := strings.TrimSuffix(name, ext)
+	idx := strings.LastIndex(base, ".")
+	if idx == -1 {
+		return base, model.DefaultLocale
+	}
+	locale := base[idx+1:]
+	if !model.IsSupportedLocale(locale) || locale == model.DefaultLocale {
+		return base, model.DefaultLocale
+	}
+	return base[:idx], locale
+}
+
+// translationLocales — языки, для которых ищутся файлы переводов
+func translationLocales() []string {
+	return model.SupportedLocales[1:]
+}
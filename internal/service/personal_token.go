@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// personalTokenPrefix отличает токен от JWT в заголовке Authorization и помогает сканерам секретов
+	personalTokenPrefix = "gpp_"
+	// personalTokenDisplayLen — сколько символов токена показывается в списке
+	personalTokenDisplayLen    = 12
+	personalTokenMaxPerUser    = 20
+	personalTokenMaxNameLen    = 100
+	personalTokenTouchInterval = time.Minute
+)
+
+var (
+	ErrPersonalTokenNotFound = errors.New("personal token not found")
+	ErrInvalidPersonalToken  = errors.New("invalid or expired personal token")
+	ErrInvalidTokenName      = errors.New("token name is required and must be at most 100 characters")
+	ErrInvalidScope          = errors.New("unknown or empty scopes")
+	ErrTokenExpiryPast       = errors.New("token expiry must be in the future")
+	ErrTooManyTokens         = errors.New("too many personal tokens")
+)
+
+type PersonalTokenService struct {
+	log       *zap.Logger
+	tokenRepo repository.PersonalTokenRepository
+}
+
+func NewPersonalTokenService(log *zap.Logger, tokenRepo repository.PersonalTokenRepository) *PersonalTokenService {
+	return &PersonalTokenService{log: log, tokenRepo: tokenRepo}
+}
+
+// IsPersonalToken — похоже ли значение Bearer на personal access token, а не на JWT
+func IsPersonalToken(token string) bool {
+	return strings.HasPrefix(token, personalTokenPrefix)
+}
+
+// Create выпускает токен; значение возвращается только здесь, в базе остаётся хеш
+func (s *PersonalTokenService) Create(ctx context.Context, userID uuid.UUID, name string, scopes []model.Scope, expiresAt *time.Time) (*model.CreatedPersonalToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > personalTokenMaxNameLen {
+		return nil, ErrInvalidTokenName
+	}
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(model.Scopes, scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, ErrTokenExpiryPast
+	}
+
+	count, err := s.tokenRepo.CountByUser(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to count personal tokens", zap.Error(err))
+		return nil, err
+	}
+	if count >= personalTokenMaxPerUser {
+		return nil, ErrTooManyTokens
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("generate token: %w", err)
+	}
+	value := personalTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	slices.Sort(scopes)
+	token := &model.PersonalToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    value[:personalTokenDisplayLen],
+		Hash:      hashPersonalToken(value),
+		Scopes:    slices.Compact(scopes),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		s.log.Error("failed to create personal token", zap.Error(err))
+		return nil, err
+	}
+
+	return &model.CreatedPersonalToken{PersonalToken: *token, Token: value}, nil
+}
+
+func (s *PersonalTokenService) List(ctx context.Context, userID uuid.UUID) ([]model.PersonalToken, error) {
+	tokens, err := s.tokenRepo.ListByUser(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to list personal tokens", zap.Error(err))
+	}
+	return tokens, err
+}
+
+func (s *PersonalTokenService) Revoke(ctx context.Context, userID, tokenID uuid.UUID) error {
+	err := s.tokenRepo.Delete(ctx, userID, tokenID)
+	if errors.Is(err, repository.PersonalTokenNotFound) {
+		return ErrPersonalTokenNotFound
+	}
+	if err != nil {
+		s.log.Error("failed to delete personal token", zap.Error(err))
+	}
+	return err
+}
+
+// Authenticate находит токен по значению и проверяет срок; права проверяет middleware
+func (s *PersonalTokenService) Authenticate(ctx context.Context, value string) (*model.PersonalToken, error) {
+	token, err := s.tokenRepo.GetByHash(ctx, hashPersonalToken(value))
+	if errors.Is(err, repository.PersonalTokenNotFound) {
+		return nil, ErrInvalidPersonalToken
+	}
+	if err != nil {
+		s.log.Error("failed to get personal token", zap.Error(err))
+		return nil, err
+	}
+	if token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt) {
+		return nil, ErrInvalidPersonalToken
+	}
+
+	if err := s.tokenRepo.Touch(ctx, token.ID, personalTokenTouchInterval); err != nil {
+		s.log.Error("failed to update personal token last use", zap.Error(err))
+	}
+	return token, nil
+}
+
+// hashPersonalToken — SHA-256 без соли: у токена 256 бит случайности, перебор по хешу бессмыслен
+func hashPersonalToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
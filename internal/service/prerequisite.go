@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/config"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var ErrItemLocked = errors.New("prerequisites not completed")
+
+const (
+	lessonRefPrefix = "theory/"
+	taskRefPrefix   = "tasks/"
+	stepRefPrefix   = "projects/"
+)
+
+func LessonRef(chapterSlug, lessonSlug string) string {
+	return lessonRefPrefix + chapterSlug + "/" + lessonSlug
+}
+
+func TaskRef(chapterSlug, taskSlug string) string {
+	return taskRefPrefix + chapterSlug + "/" + taskSlug
+}
+
+func StepRef(projectSlug, stepSlug string) string {
+	return stepRefPrefix + projectSlug + "/" + stepSlug
+}
+
+// PrerequisiteService хранит граф зависимостей между уроками, задачами и шагами проектов.
+// Узлы регистрируются сервисами контента при загрузке, после чего граф проверяется через Validate.
+type PrerequisiteService struct {
+	log            *zap.Logger
+	progressRepo   repository.TheoryProgressRepository
+	submissionRepo repository.SubmissionRepository
+	enforce        bool
+	requires       map[string][]string
+}
+
+func NewPrerequisiteService(
+	log *zap.Logger,
+	progressRepo repository.TheoryProgressRepository,
+	submissionRepo repository.SubmissionRepository,
+	prerequisitesCfg config.PrerequisitesConfig,
+) *PrerequisiteService {
+	return &PrerequisiteService{
+		log:            log,
+		progressRepo:   progressRepo,
+		submissionRepo: submissionRepo,
+		enforce:        prerequisitesCfg.Enforce,
+		requires:       make(map[string][]string),
+	}
+}
+
+func (s *PrerequisiteService) register(ref string, requires []string) {
+	s.requires[ref] = requires
+}
+
+func (s *PrerequisiteService) registered(ref string) bool {
+	_, ok := s.requires[ref]
+	return ok
+}
+
+// Validate проверяет, что все ссылки в requires существуют и граф не содержит циклов
+func (s *PrerequisiteService) Validate() error {
+	for ref, requires := range s.requires {
+		for _, req := range requires {
+			if _, ok := s.requires[req]; !ok {
+				return fmt.Errorf("%s requires unknown item %s", ref, req)
+			}
+		}
+	}
+
+	// решения задач и шагов неотличимы в submissions, поэтому их адреса не должны совпадать
+	for ref := range s.requires {
+		if rest, ok := strings.CutPrefix(ref, taskRefPrefix); ok && s.registered(stepRefPrefix+rest) {
+			return fmt.Errorf("task %s conflicts with project step %s", ref, stepRefPrefix+rest)
+		}
+	}
+
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int, len(s.requires))
+	var path []string
+
+	var visit func(ref string) error
+	visit = func(ref string) error {
+		switch state[ref] {
+		case done:
+			return nil
+		case inProgress:
+			return fmt.Errorf("prerequisite cycle: %s -> %s", strings.Join(path, " -> "), ref)
+		}
+
+		state[ref] = inProgress
+		path = append(path, ref)
+		for _, req := range s.requires[ref] {
+			if err := visit(req); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[ref] = done
+		return nil
+	}
+
+	for ref := range s.requires {
+		if err := visit(ref); err != nil {
+			return err
+		}
+	}
+
+	edges := 0
+	for _, requires := range s.requires {
+		edges += len(requires)
+	}
+	s.log.Info("prerequisites validated", zap.Int("items", len(s.requires)), zap.Int("edges", edges))
+
+	return nil
+}
+
+// DoneSet возвращает ссылки на пройденные пользователем уроки, задачи и шаги проектов
+func (s *PrerequisiteService) DoneSet(ctx context.Context, userID uuid.UUID) (map[string]bool, error) {
+	completed, err := s.progressRepo.GetCompletedTheories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	solved, err := s.submissionRepo.GetSolvedTasks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[string]bool)
+	for chapterSlug, lessons := range completed {
+		for lessonSlug := range lessons {
+			done[LessonRef(chapterSlug, lessonSlug)] = true
+		}
+	}
+	// задачи и шаги проектов хранятся в одной таблице submissions; вид решения
+	// определяется по зарегистрированному узлу, Validate исключает совпадения
+	for _, st := range solved {
+		if ref := TaskRef(st.ChapterSlug, st.TaskSlug); s.registered(ref) {
+			done[ref] = true
+		} else if ref := StepRef(st.ChapterSlug, st.TaskSlug); s.registered(ref) {
+			done[ref] = true
+		}
+	}
+
+	return done, nil
+}
+
+// IsLocked — есть ли у элемента непройденные зависимости
+func (s *PrerequisiteService) IsLocked(ref string, done map[string]bool) bool {
+	for _, req := range s.requires[ref] {
+		if !done[req] {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckUnlocked возвращает ErrItemLocked, если блокировка включена и зависимости не пройдены.
+// В рекомендательном режиме всегда возвращает nil.
+func (s *PrerequisiteService) CheckUnlocked(ctx context.Context, userID uuid.UUID, ref string) error {
+	if !s.enforce || len(s.requires[ref]) == 0 {
+		return nil
+	}
+
+	done, err := s.DoneSet(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to load progress for prerequisites", zap.Error(err))
+		return err
+	}
+	if s.IsLocked(ref, done) {
+		return ErrItemLocked
+	}
+	return nil
+}
+
+// lockState — множество пройденного для авторизованного пользователя, nil для гостя
+func (s *PrerequisiteService) lockState(ctx context.Context, userID *uuid.UUID) map[string]bool {
+	if userID == nil {
+		return nil
+	}
+	done, err := s.DoneSet(ctx, *userID)
+	if err != nil {
+		s.log.Error("failed to load progress for prerequisites", zap.Error(err))
+		return nil
+	}
+	return done
+}
+
+// locked возвращает nil для гостя, иначе признак блокировки
+func (s *PrerequisiteService) locked(ref string, done map[string]bool) *bool {
+	if done == nil {
+		return nil
+	}
+	v := s.IsLocked(ref, done)
+	return &v
+}
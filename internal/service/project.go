@@ -3,11 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/fs"
 	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/GlebMoskalev/go-path-backend/internal/completion"
 	"github.com/GlebMoskalev/go-path-backend/internal/model"
 	"github.com/GlebMoskalev/go-path-backend/internal/repository"
 	"github.com/google/uuid"
@@ -23,23 +25,40 @@ var (
 type ProjectService struct {
 	log            *zap.Logger
 	submissionRepo repository.SubmissionRepository
-	projects       []model.Project
-	steps          map[string]map[string]model.ProjectStep
-	references     map[string]map[string]map[string]string
-	tests          map[string]map[string]map[string]string
-	goMods         map[string]string
-	stepOrder      map[string][]string
+	prereq         *PrerequisiteService
+	theory         *TheoryService
+	// completionGen заполняет пустые completions.yaml при загрузке; nil = выключено
+	completionGen *completion.Generator
+	projects      []model.Project
+	steps         map[string]map[string]model.ProjectStep
+	references    map[string]map[string]map[string]string
+	tests         map[string]map[string]map[string]string
+	goMods        map[string]string
+	stepOrder     map[string][]string
+	// translations: locale -> project -> step, только переведённые шаги
+	translations        map[string]map[string]map[string]model.ProjectStep
+	projectTranslations map[string]map[string]model.ProjectMeta
 }
 
-func NewProjectService(fsys fs.FS, root string, log *zap.Logger, submissionRepo repository.SubmissionRepository) (*ProjectService, error) {
+func NewProjectService(
+	fsys fs.FS, root string, log *zap.Logger,
+	submissionRepo repository.SubmissionRepository, prereq *PrerequisiteService, theory *TheoryService,
+	completionGen *completion.Generator,
+) (*ProjectService, error) {
 	s := &ProjectService{
 		log:            log,
 		submissionRepo: submissionRepo,
+		prereq:         prereq,
+		theory:         theory,
+		completionGen:  completionGen,
 		steps:          make(map[string]map[string]model.ProjectStep),
 		references:     make(map[string]map[string]map[string]string),
 		tests:          make(map[string]map[string]map[string]string),
 		goMods:         make(map[string]string),
 		stepOrder:      make(map[string][]string),
+
+		translations:        make(map[string]map[string]map[string]model.ProjectStep),
+		projectTranslations: make(map[string]map[string]model.ProjectMeta),
 	}
 	if err := s.load(fsys, root); err != nil {
 		return nil, err
@@ -47,26 +66,29 @@ func NewProjectService(fsys fs.FS, root string, log *zap.Logger, submissionRepo
 	return s, nil
 }
 
-func (s *ProjectService) ListProjects(ctx context.Context, userID *uuid.UUID) []model.Project {
+func (s *ProjectService) ListProjects(ctx context.Context, userID *uuid.UUID, locale string) []model.Project {
 	result := make([]model.Project, len(s.projects))
 	for i, p := range s.projects {
-		result[i] = model.Project{
+		result[i] = s.localizeProject(model.Project{
 			Slug:        p.Slug,
 			Title:       p.Title,
 			Description: p.Description,
 			Order:       p.Order,
 			Steps:       stripStepContent(p.Steps),
-		}
+			Locale:      p.Locale,
+		}, locale)
 	}
 
 	if userID != nil {
 		solved := s.getSolvedProjectSet(ctx, *userID)
+		done := s.prereq.lockState(ctx, userID)
 		for i := range result {
 			count := 0
 			for j := range result[i].Steps {
 				key := result[i].Slug + "/" + result[i].Steps[j].Slug
 				v := solved[key]
 				result[i].Steps[j].Solved = &v
+				result[i].Steps[j].Locked = s.prereq.locked(StepRef(result[i].Slug, result[i].Steps[j].Slug), done)
 				if v {
 					count++
 				}
@@ -79,23 +101,26 @@ func (s *ProjectService) ListProjects(ctx context.Context, userID *uuid.UUID) []
 	return result
 }
 
-func (s *ProjectService) GetProject(ctx context.Context, slug string, userID *uuid.UUID) (model.Project, error) {
+func (s *ProjectService) GetProject(ctx context.Context, slug string, userID *uuid.UUID, locale string) (model.Project, error) {
 	for _, p := range s.projects {
 		if p.Slug == slug {
-			result := model.Project{
+			result := s.localizeProject(model.Project{
 				Slug:        p.Slug,
 				Title:       p.Title,
 				Description: p.Description,
 				Order:       p.Order,
 				Steps:       stripStepContent(p.Steps),
-			}
+				Locale:      p.Locale,
+			}, locale)
 			if userID != nil {
 				solved := s.getSolvedProjectSet(ctx, *userID)
+				done := s.prereq.lockState(ctx, userID)
 				count := 0
 				for i := range result.Steps {
 					key := slug + "/" + result.Steps[i].Slug
 					v := solved[key]
 					result.Steps[i].Solved = &v
+					result.Steps[i].Locked = s.prereq.locked(StepRef(slug, result.Steps[i].Slug), done)
 					if v {
 						count++
 					}
@@ -108,7 +133,7 @@ func (s *ProjectService) GetProject(ctx context.Context, slug string, userID *uu
 	return model.Project{}, ErrProjectNotFound
 }
 
-func (s *ProjectService) GetStep(ctx context.Context, projectSlug, stepSlug string, userID *uuid.UUID) (model.ProjectStep, error) {
+func (s *ProjectService) GetStep(ctx context.Context, projectSlug, stepSlug string, userID *uuid.UUID, locale string) (model.ProjectStep, error) {
 	projectSteps, ok := s.steps[projectSlug]
 	if !ok {
 		return model.ProjectStep{}, ErrProjectNotFound
@@ -117,6 +142,8 @@ func (s *ProjectService) GetStep(ctx context.Context, projectSlug, stepSlug stri
 	if !ok {
 		return model.ProjectStep{}, ErrProjectStepNotFound
 	}
+	step = s.localizeStep(step, locale)
+	step.RelatedLessons = s.theory.localizeLessonLinks(step.RelatedLessons, locale)
 	if userID != nil {
 		submissions, err := s.submissionRepo.ListByUserAndTask(ctx, *userID, projectSlug, stepSlug)
 		if err != nil {
@@ -132,7 +159,7 @@ func (s *ProjectService) GetStep(ctx context.Context, projectSlug, stepSlug stri
 		}
 
 		step.Submissions = submissions
-
+		step.Locked = s.prereq.locked(StepRef(projectSlug, stepSlug), s.prereq.lockState(ctx, userID))
 	}
 	return step, nil
 }
@@ -241,6 +268,10 @@ func (s *ProjectService) load(fsys fs.FS, root string) error {
 			continue
 		}
 		s.projects = append(s.projects, project)
+
+		if err := s.linkLessons(project); err != nil {
+			return err
+		}
 	}
 
 	sort.Slice(s.projects, func(i, j int) bool {
@@ -297,9 +328,12 @@ func (s *ProjectService) loadProject(fsys fs.FS, root, dirName string) (model.Pr
 		step.ProjectTitle = meta.Title
 		steps = append(steps, step)
 		s.steps[dirName][step.Slug] = step
+		s.prereq.register(StepRef(dirName, step.Slug), step.Requires)
 		s.references[dirName][step.Slug] = refs
 		s.tests[dirName][step.Slug] = testFiles
+		s.loadStepTranslations(fsys, filepath.Join(stepsPath, sd.Name()), dirName, step.Slug)
 	}
+	s.loadProjectTranslations(fsys, projectPath, dirName)
 
 	sort.Slice(steps, func(i, j int) bool {
 		return steps[i].Order < steps[j].Order
@@ -311,15 +345,74 @@ func (s *ProjectService) loadProject(fsys fs.FS, root, dirName string) (model.Pr
 	}
 	s.stepOrder[dirName] = orderedSlugs
 
+	if s.completionGen != nil {
+		s.generateCompletions(dirName, steps)
+	}
+
 	return model.Project{
 		Slug:        dirName,
 		Title:       meta.Title,
 		Description: meta.Description,
 		Order:       meta.Order,
 		Steps:       steps,
+		Locale:      model.DefaultLocale,
 	}, nil
 }
 
+// loadProjectTranslations читает meta.<locale>.yaml проекта, если они есть
+func (s *ProjectService) loadProjectTranslations(fsys fs.FS, projectPath, projectSlug string) {
+	for _, locale := range translationLocales() {
+		metaData, err := fs.ReadFile(fsys, filepath.Join(projectPath, localizedName("meta", ".yaml", locale)))
+		if err != nil {
+			continue
+		}
+
+		var meta model.ProjectMeta
+		if err := yaml.Unmarshal(metaData, &meta); err != nil {
+			s.log.Warn("skipping project translation",
+				zap.String("project", projectSlug), zap.String("locale", locale), zap.Error(err))
+			continue
+		}
+
+		if s.projectTranslations[locale] == nil {
+			s.projectTranslations[locale] = make(map[string]model.ProjectMeta)
+		}
+		s.projectTranslations[locale][projectSlug] = meta
+	}
+}
+
+// loadStepTranslations читает task.<locale>.md шага, если они есть
+func (s *ProjectService) loadStepTranslations(fsys fs.FS, stepPath, projectSlug, stepSlug string) {
+	for _, locale := range translationLocales() {
+		taskData, err := fs.ReadFile(fsys, filepath.Join(stepPath, localizedName("task", ".md", locale)))
+		if err != nil {
+			continue
+		}
+
+		fm, description, err := parseStepFrontmatter(string(taskData))
+		if err != nil {
+			s.log.Warn("skipping step translation",
+				zap.String("project", projectSlug), zap.String("step", stepSlug), zap.String("locale", locale), zap.Error(err))
+			continue
+		}
+
+		if s.translations[locale] == nil {
+			s.translations[locale] = make(map[string]map[string]model.ProjectStep)
+		}
+		if s.translations[locale][projectSlug] == nil {
+			s.translations[locale][projectSlug] = make(map[string]model.ProjectStep)
+		}
+		s.translations[locale][projectSlug][stepSlug] = model.ProjectStep{
+			Slug:        stepSlug,
+			Title:       fm.Title,
+			Description: description,
+			Hints:       fm.Hints,
+			ProjectSlug: projectSlug,
+			Locale:      locale,
+		}
+	}
+}
+
 func (s *ProjectService) loadStep(fsys fs.FS, stepsPath, dirName, projectSlug string) (model.ProjectStep, map[string]string, map[string]string, error) {
 	stepPath := filepath.Join(stepsPath, dirName)
 
@@ -360,6 +453,11 @@ func (s *ProjectService) loadStep(fsys fs.FS, stepsPath, dirName, projectSlug st
 	}
 	completions := completionsWrapper.Completions
 
+	relatedLessons, err := parseLessonLinks(fm.RelatedLessons)
+	if err != nil {
+		return model.ProjectStep{}, nil, nil, err
+	}
+
 	step := model.ProjectStep{
 		Slug:        dirName,
 		Title:       fm.Title,
@@ -371,6 +469,10 @@ func (s *ProjectService) loadStep(fsys fs.FS, stepsPath, dirName, projectSlug st
 		Order:       fm.Order,
 		ProjectSlug: projectSlug,
 		Completions: completions,
+		Locale:      model.DefaultLocale,
+		Requires:    fm.Requires,
+
+		RelatedLessons: relatedLessons,
 	}
 
 	if step.File == "" && len(refs) == 1 {
@@ -382,6 +484,61 @@ func (s *ProjectService) loadStep(fsys fs.FS, stepsPath, dirName, projectSlug st
 	return step, refs, testFiles, nil
 }
 
+// generateCompletions заполняет пустые подсказки шагов; steps отсортированы по порядку прохождения,
+// так что каждому шагу доступны пакеты reference/ предыдущих шагов
+func (s *ProjectService) generateCompletions(projectSlug string, steps []model.ProjectStep) {
+	files := make([]completion.Step, len(steps))
+	for i, step := range steps {
+		files[i] = completion.Step{Template: step.Template, Reference: s.references[projectSlug][step.Slug]}
+	}
+	sources := completion.ProjectSources(completion.ModulePath(s.goMods[projectSlug]), files)
+
+	for i := range steps {
+		if len(steps[i].Completions) > 0 {
+			continue
+		}
+		completions, err := s.completionGen.Generate(sources[i])
+		if err != nil {
+			s.log.Warn("failed to generate completions",
+				zap.String("project", projectSlug), zap.String("step", steps[i].Slug), zap.Error(err))
+			continue
+		}
+		steps[i].Completions = completions
+		s.steps[projectSlug][steps[i].Slug] = steps[i]
+	}
+}
+
+// linkLessons проверяет related_lessons шагов проекта и регистрирует шаги в уроках теории
+func (s *ProjectService) linkLessons(project model.Project) error {
+	for _, step := range project.Steps {
+		if len(step.RelatedLessons) == 0 {
+			continue
+		}
+
+		titles := make(map[string]string)
+		for locale, projects := range s.translations {
+			if tr, ok := projects[project.Slug][step.Slug]; ok {
+				titles[locale] = tr.Title
+			}
+		}
+
+		links, err := s.theory.linkPractice(step.RelatedLessons, model.PracticeTask{
+			Type:        model.PracticeTypeStep,
+			ChapterSlug: project.Slug,
+			Slug:        step.Slug,
+			Title:       step.Title,
+			Difficulty:  step.Difficulty,
+		}, StepRef(project.Slug, step.Slug), titles)
+		if err != nil {
+			return fmt.Errorf("step %s/%s related_lessons: %w", project.Slug, step.Slug, err)
+		}
+
+		step.RelatedLessons = links
+		s.steps[project.Slug][step.Slug] = step
+	}
+	return nil
+}
+
 func parseStepFrontmatter(raw string) (model.StepFrontmatter, string, error) {
 	const delimiter = "---"
 	raw = strings.TrimSpace(raw)
@@ -432,11 +589,47 @@ func stripStepContent(steps []model.ProjectStep) []model.ProjectStep {
 			Difficulty:  s.Difficulty,
 			Order:       s.Order,
 			ProjectSlug: s.ProjectSlug,
+			Locale:      s.Locale,
+			Requires:    s.Requires,
 		}
 	}
 	return stripped
 }
 
+// localizeProject подменяет заголовки проекта и его шагов переводом, если он есть
+func (s *ProjectService) localizeProject(p model.Project, locale string) model.Project {
+	if meta, ok := s.projectTranslations[locale][p.Slug]; ok {
+		p.Title = meta.Title
+		p.Description = meta.Description
+		p.Locale = locale
+	}
+	for i := range p.Steps {
+		p.Steps[i] = s.localizeStep(p.Steps[i], locale)
+	}
+	return p
+}
+
+// localizeStep подменяет шаг переводом; без перевода шаг остаётся на языке по умолчанию
+func (s *ProjectService) localizeStep(step model.ProjectStep, locale string) model.ProjectStep {
+	if meta, ok := s.projectTranslations[locale][step.ProjectSlug]; ok && step.ProjectTitle != "" {
+		step.ProjectTitle = meta.Title
+	}
+
+	tr, ok := s.translations[locale][step.ProjectSlug][step.Slug]
+	if !ok {
+		return step
+	}
+	step.Title = tr.Title
+	if step.Description != "" {
+		step.Description = tr.Description
+	}
+	if step.Hints != nil {
+		step.Hints = tr.Hints
+	}
+	step.Locale = locale
+	return step
+}
+
 func (s *ProjectService) totalSteps() int {
 	count := 0
 	for _, p := range s.projects {
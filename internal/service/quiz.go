@@ -1,32 +1,88 @@
 package service
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
 	"math/rand"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
 
+	"github.com/GlebMoskalev/go-path-backend/internal/config"
 	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 )
 
-var ErrQuestionNotFound = errors.New("question not found")
+// quizIDPattern — допустимые явные id вопросов и вариантов; ":" занят разделителем главы
+var quizIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+var (
+	ErrQuestionNotFound = errors.New("question not found")
+	ErrQuestionNoCode   = errors.New("question has no code")
+)
 
 type QuizService struct {
-	log       *zap.Logger
-	questions map[string][]model.QuizQuestion
-	allByID   map[string]model.QuizQuestion
-	chapters  []model.QuizChapterInfo
+	log            *zap.Logger
+	sandboxService *SandboxService
+	theory         *TheoryService
+	attemptRepo    repository.QuizAttemptRepository
+	reviewRepo     repository.QuizReviewRepository
+	examRepo       repository.QuizExamRepository
+	cfg            config.QuizConfig
+	questions      map[string][]model.QuizQuestion
+	allByID        map[string]model.QuizQuestion
+	chapters       []model.QuizChapterInfo
+	// lessonQuestions: LessonRef -> id вопросов мини-квиза урока в порядке файла
+	lessonQuestions map[string][]string
+	// translations: locale -> question id, только переведённые вопросы
+	translations  map[string]map[string]model.QuizQuestion
+	chapterTitles map[string]map[string]string
+	// patterns: скомпилированные regex правил accept вопросов text
+	patterns map[string]*regexp.Regexp
+
+	// outputs: код вопроса -> результат запуска; код не меняется без перезапуска сервера
+	outputsMu sync.RWMutex
+	outputs   map[string]model.RunResult
 }
 
-func NewQuizService(fsys fs.FS, root string, log *zap.Logger) (*QuizService, error) {
+func NewQuizService(
+	fsys fs.FS, root string, log *zap.Logger,
+	sandboxService *SandboxService, theory *TheoryService,
+	attemptRepo repository.QuizAttemptRepository, reviewRepo repository.QuizReviewRepository,
+	examRepo repository.QuizExamRepository, quizCfg config.QuizConfig,
+) (*QuizService, error) {
+	// пустой ключ сделал бы подпись экзаменационных токенов подделываемой
+	if examRepo != nil && quizCfg.ExamSecret == "" {
+		return nil, errors.New("QUIZ_EXAM_SECRET or JWT_SECRET must be set")
+	}
 	s := &QuizService{
-		log:       log,
-		questions: make(map[string][]model.QuizQuestion),
-		allByID:   make(map[string]model.QuizQuestion),
+		log:            log,
+		sandboxService: sandboxService,
+		theory:         theory,
+		attemptRepo:    attemptRepo,
+		reviewRepo:     reviewRepo,
+		examRepo:       examRepo,
+		cfg:            quizCfg,
+		questions:      make(map[string][]model.QuizQuestion),
+		allByID:        make(map[string]model.QuizQuestion),
+
+		lessonQuestions: make(map[string][]string),
+
+		translations:  make(map[string]map[string]model.QuizQuestion),
+		chapterTitles: make(map[string]map[string]string),
+		patterns:      make(map[string]*regexp.Regexp),
+		outputs:       make(map[string]model.RunResult),
 	}
 
 	if err := s.load(fsys, root); err != nil {
@@ -36,11 +92,21 @@ func NewQuizService(fsys fs.FS, root string, log *zap.Logger) (*QuizService, err
 	return s, nil
 }
 
-func (s *QuizService) ListChapters() []model.QuizChapterInfo {
-	return s.chapters
+func (s *QuizService) ListChapters(locale string) []model.QuizChapterInfo {
+	result := make([]model.QuizChapterInfo, len(s.chapters))
+	for i, ch := range s.chapters {
+		if title, ok := s.chapterTitles[locale][ch.Slug]; ok {
+			ch.Title = title
+			ch.Locale = locale
+		}
+		result[i] = ch
+	}
+	return result
 }
 
-func (s *QuizService) GetQuestions(chapterSlugs []string, limit int) []model.QuizQuestion {
+// GetQuestions выбирает вопросы в случайном порядке. С userID вперёд идут вопросы,
+// которые пользователь не видел, затем те, на которые он ответил неверно.
+func (s *QuizService) GetQuestions(ctx context.Context, userID *uuid.UUID, chapterSlugs []string, limit int, locale string) []model.QuizQuestion {
 	var pool []model.QuizQuestion
 	if len(chapterSlugs) == 0 {
 		// все главы
@@ -59,37 +125,214 @@ func (s *QuizService) GetQuestions(chapterSlugs []string, limit int) []model.Qui
 		pool[i], pool[j] = pool[j], pool[i]
 	})
 
+	if userID != nil {
+		s.preferUnseen(ctx, *userID, pool)
+	}
+
 	if limit > 0 && limit < len(pool) {
 		pool = pool[:limit]
 	}
 
 	for i := range pool {
-		opts := make([]string, len(pool[i].Options))
-		copy(opts, pool[i].Options)
-		rand.Shuffle(len(opts), func(a, b int) {
-			opts[a], opts[b] = opts[b], opts[a]
-		})
-		pool[i].Options = opts
+		pool[i] = s.present(pool[i], locale)
 	}
 
 	return pool
 }
 
-func (s *QuizService) CheckAnswer(questionID string, answerText string) (model.QuizAnswerResponse, error) {
+// present готовит вопрос к выдаче: перевод и перемешанная копия вариантов
+func (s *QuizService) present(q model.QuizQuestion, locale string) model.QuizQuestion {
+	q = s.localize(q, locale)
+	opts := make([]model.QuizOption, len(q.Options))
+	copy(opts, q.Options)
+	rand.Shuffle(len(opts), func(a, b int) {
+		opts[a], opts[b] = opts[b], opts[a]
+	})
+	q.Options = opts
+	return q
+}
+
+// CheckAnswer проверяет ответ вне попытки. Вопросы идущего экзамена пользователя
+// не проверяются, иначе верный ответ можно было бы узнать до сдачи.
+func (s *QuizService) CheckAnswer(ctx context.Context, userID uuid.UUID, questionID string, answers []string, locale string) (model.QuizAnswerResponse, error) {
+	if err := s.guardExam(ctx, userID, questionID); err != nil {
+		return model.QuizAnswerResponse{}, err
+	}
+	result, err := s.check(questionID, answers, locale)
+	if err != nil {
+		return model.QuizAnswerResponse{}, err
+	}
+	s.scheduleAnswer(ctx, userID, questionID, result.Score)
+	return result, nil
+}
+
+func (s *QuizService) check(questionID string, answers []string, locale string) (model.QuizAnswerResponse, error) {
 	q, ok := s.allByID[questionID]
 	if !ok {
 		return model.QuizAnswerResponse{}, ErrQuestionNotFound
 	}
+	q = s.localize(q, locale)
 
-	correctText := q.Options[q.Answer]
+	return s.grade(q, answers), nil
+}
 
-	return model.QuizAnswerResponse{
-		Correct:       answerText == correctText,
-		CorrectAnswer: correctText,
-		Explanation:   q.Explanation,
+// preferUnseen упорядочивает pool: не виденные, отвеченные неверно, отвеченные верно.
+// Внутри группы сохраняется случайный порядок.
+func (s *QuizService) preferUnseen(ctx context.Context, userID uuid.UUID, pool []model.QuizQuestion) {
+	history, err := s.attemptRepo.GetQuestionHistory(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to get quiz history", zap.Error(err))
+		return
+	}
+
+	rank := func(q model.QuizQuestion) int {
+		correct, seen := history[q.ID]
+		switch {
+		case !seen:
+			return 0
+		case !correct:
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(pool, func(i, j int) bool {
+		return rank(pool[i]) < rank(pool[j])
+	})
+}
+
+// RunCode проверяет ответ и показывает настоящий вывод кода вопроса.
+// Вывод отдаётся только вместе с ответом, иначе он раскрывал бы верный вариант.
+func (s *QuizService) RunCode(ctx context.Context, userID uuid.UUID, questionID string, answers []string, locale string) (model.QuizRunResponse, error) {
+	if err := s.guardExam(ctx, userID, questionID); err != nil {
+		return model.QuizRunResponse{}, err
+	}
+
+	q, ok := s.allByID[questionID]
+	if !ok {
+		return model.QuizRunResponse{}, ErrQuestionNotFound
+	}
+	q = s.localize(q, locale)
+	if q.Code == "" {
+		return model.QuizRunResponse{}, ErrQuestionNoCode
+	}
+
+	result := s.grade(q, answers)
+	s.scheduleAnswer(ctx, userID, questionID, result.Score)
+
+	return model.QuizRunResponse{
+		QuizAnswerResponse: result,
+		Run:                s.runCode(ctx, q.Code),
 	}, nil
 }
 
+// VerifyCode запускает код всех вопросов с code: и сверяет вывод с вариантом answer,
+// с правилами accept у вопросов text или с порядком lines у вопросов order.
+// Возвращает все расхождения разом, чтобы автор контента исправил их за один проход.
+func (s *QuizService) VerifyCode(ctx context.Context) error {
+	var questions []model.QuizQuestion
+	for _, ch := range s.chapters {
+		questions = append(questions, s.questions[ch.Slug]...)
+	}
+	for _, locale := range translationLocales() {
+		for _, ch := range s.chapters {
+			for _, q := range s.questions[ch.Slug] {
+				if tr, ok := s.translations[locale][q.ID]; ok {
+					questions = append(questions, tr)
+				}
+			}
+		}
+	}
+
+	var errs []error
+	verified := 0
+	for _, q := range questions {
+		if q.Code == "" {
+			continue
+		}
+		if err := s.verifyQuestion(ctx, q); err != nil {
+			errs = append(errs, fmt.Errorf("question %s (%s): %w", q.ID, q.Locale, err))
+			continue
+		}
+		verified++
+	}
+
+	s.log.Info("quiz code verified", zap.Int("questions", verified), zap.Int("failed", len(errs)))
+	return errors.Join(errs...)
+}
+
+func (s *QuizService) verifyQuestion(ctx context.Context, q model.QuizQuestion) error {
+	run := s.runCode(ctx, q.Code)
+	if run.Error != "" || run.ExitCode != 0 {
+		return fmt.Errorf("code failed (exit %d): %s", run.ExitCode, strings.TrimSpace(run.Error))
+	}
+
+	switch q.Type {
+	case model.QuizTypeText:
+		if !s.accepts(q.Accept, run.Output) {
+			return fmt.Errorf("output %q is not accepted", run.Output)
+		}
+		return nil
+	case model.QuizTypeOrder:
+		// каждая строка вывода — одна строка ответа, пустые строки не считаются
+		var printed []string
+		for _, line := range strings.Split(run.Output, "\n") {
+			if line = normalizeSpace(line); line != "" {
+				printed = append(printed, line)
+			}
+		}
+		if !slices.EqualFunc(printed, q.Lines, func(a string, b model.QuizOption) bool { return a == normalizeSpace(b.Text) }) {
+			return fmt.Errorf("output lines %q do not match lines", printed)
+		}
+		return nil
+	}
+
+	derived := -1
+	for i, opt := range q.Options {
+		if sameOutput(opt.Text, run.Output) {
+			derived = i
+			break
+		}
+	}
+	switch {
+	case derived < 0:
+		return fmt.Errorf("no option matches output %q", run.Output)
+	case derived != q.Answer:
+		return fmt.Errorf("answer is %d, but output %q matches option %d", q.Answer, run.Output, derived)
+	}
+	return nil
+}
+
+// runCode выполняет код вопроса; удачные запуски кэшируются, сбои песочницы — нет
+func (s *QuizService) runCode(ctx context.Context, code string) model.RunResult {
+	s.outputsMu.RLock()
+	run, ok := s.outputs[code]
+	s.outputsMu.RUnlock()
+	if ok {
+		return run
+	}
+
+	files := map[string]string{
+		"go.mod":  "module quiz\n\ngo 1.25\n",
+		"main.go": wrapSnippet(code),
+	}
+	run = s.sandboxService.RunProgram(ctx, files)
+	if run.Error == sandboxInternalError || run.Error == "execution timeout" {
+		return run
+	}
+
+	s.outputsMu.Lock()
+	s.outputs[code] = run
+	s.outputsMu.Unlock()
+	return run
+}
+
+// sameOutput сравнивает вывод с вариантом без учёта пробелов и переводов строк:
+// вариант "два три" соответствует выводу "два\nтри\n"
+func sameOutput(option, output string) bool {
+	return normalizeSpace(option) == normalizeSpace(output)
+}
+
 func (s *QuizService) load(fsys fs.FS, root string) error {
 	dirs, err := fs.ReadDir(fsys, root)
 	if err != nil {
@@ -131,17 +374,49 @@ func (s *QuizService) load(fsys fs.FS, root string) error {
 			continue
 		}
 
+		invalid := false
 		for i := range raw.Questions {
-			raw.Questions[i].ID = fmt.Sprintf("%s:%d", chapterSlug, i)
-			raw.Questions[i].ChapterSlug = chapterSlug
-			s.allByID[raw.Questions[i].ID] = raw.Questions[i]
+			q := &raw.Questions[i]
+			q.ChapterSlug = chapterSlug
+			q.Locale = model.DefaultLocale
+
+			err := assignQuestionIDs(q, chapterSlug)
+			if err == nil {
+				err = s.prepareQuestion(q)
+			}
+			q.Revision = questionRevision(*q)
+			if err == nil {
+				if _, dup := s.allByID[q.ID]; dup {
+					err = fmt.Errorf("duplicate question id %s", q.ID)
+				}
+			}
+			if err == nil && q.Lesson != "" && s.theory != nil {
+				if err = s.theory.lessonExists(chapterSlug, q.Lesson); err != nil {
+					err = fmt.Errorf("%w: %s/%s", err, chapterSlug, q.Lesson)
+				}
+			}
+			if err != nil {
+				s.log.Error("invalid quiz question", zap.String("chapter", chapterSlug), zap.Int("index", i), zap.Error(err))
+				invalid = true
+				continue
+			}
+			s.allByID[q.ID] = *q
+			if q.Lesson != "" {
+				ref := LessonRef(chapterSlug, q.Lesson)
+				s.lessonQuestions[ref] = append(s.lessonQuestions[ref], q.ID)
+			}
 		}
+		if invalid {
+			return fmt.Errorf("invalid questions in %s/quiz.yaml", chapterSlug)
+		}
+		s.loadTranslations(fsys, chapterPath, chapterSlug, raw.Questions)
 
 		s.questions[chapterSlug] = raw.Questions
 		s.chapters = append(s.chapters, model.QuizChapterInfo{
 			Slug:          chapterSlug,
 			Title:         meta.Title,
 			QuestionCount: len(raw.Questions),
+			Locale:        model.DefaultLocale,
 		})
 	}
 
@@ -156,3 +431,169 @@ func (s *QuizService) load(fsys fs.FS, root string) error {
 
 	return nil
 }
+
+// loadTranslations читает quiz.<locale>.yaml и meta.<locale>.yaml главы.
+// Вопрос перевода сопоставляется с оригиналом по id, а без id — по позиции в файле.
+// Варианты перевода получают ID вариантов оригинала на тех же позициях.
+func (s *QuizService) loadTranslations(fsys fs.FS, chapterPath, chapterSlug string, originals []model.QuizQuestion) {
+	for _, locale := range translationLocales() {
+		if metaData, err := fs.ReadFile(fsys, filepath.Join(chapterPath, localizedName("meta", ".yaml", locale))); err == nil {
+			var meta struct {
+				Title string `yaml:"title"`
+			}
+			if err := yaml.Unmarshal(metaData, &meta); err == nil && meta.Title != "" {
+				if s.chapterTitles[locale] == nil {
+					s.chapterTitles[locale] = make(map[string]string)
+				}
+				s.chapterTitles[locale][chapterSlug] = meta.Title
+			}
+		}
+
+		quizData, err := fs.ReadFile(fsys, filepath.Join(chapterPath, localizedName("quiz", ".yaml", locale)))
+		if err != nil {
+			continue
+		}
+
+		var raw struct {
+			Questions []model.QuizQuestion `yaml:"questions"`
+		}
+		if err := yaml.Unmarshal(quizData, &raw); err != nil {
+			s.log.Warn("skipping quiz translation", zap.String("chapter", chapterSlug), zap.String("locale", locale), zap.Error(err))
+			continue
+		}
+
+		if len(raw.Questions) > len(originals) {
+			s.log.Warn("quiz translation has more questions than original",
+				zap.String("chapter", chapterSlug), zap.String("locale", locale))
+		}
+
+		for i, q := range raw.Questions {
+			var original model.QuizQuestion
+			switch {
+			case q.ID != "":
+				var ok bool
+				if original, ok = s.allByID[chapterSlug+":"+q.ID]; !ok {
+					s.log.Warn("quiz translation has unknown question id",
+						zap.String("chapter", chapterSlug), zap.String("locale", locale), zap.String("id", q.ID))
+					continue
+				}
+			case i < len(originals):
+				original = originals[i]
+			default:
+				continue
+			}
+
+			// тип перевода наследуется от оригинала, схема проверяется так же
+			if q.Type == "" {
+				q.Type = original.Type
+			}
+			// перевод даёт только текст: верный ответ всегда берётся из оригинала,
+			// иначе проверка зависела бы от языка. Порядок lines задают их позиции в оригинале.
+			q.Answer = original.Answer
+			q.Answers = original.Answers
+			q.Accept = original.Accept
+			// у order варианты оригинала — копия lines, их ID переносятся через lines
+			originalOptions := original.Options
+			if original.Type == model.QuizTypeOrder {
+				originalOptions = nil
+			}
+			if q.Type != original.Type ||
+				!inheritOptionIDs(q.Options, originalOptions) ||
+				!inheritOptionIDs(q.Lines, original.Lines) ||
+				s.prepareQuestion(&q) != nil {
+				s.log.Warn("skipping quiz question translation",
+					zap.String("chapter", chapterSlug), zap.String("locale", locale), zap.Int("index", i))
+				continue
+			}
+
+			q.ID = original.ID
+			q.Lesson = original.Lesson
+			q.Revision = original.Revision
+			q.ChapterSlug = chapterSlug
+			q.Locale = locale
+			if s.translations[locale] == nil {
+				s.translations[locale] = make(map[string]model.QuizQuestion)
+			}
+			s.translations[locale][q.ID] = q
+		}
+	}
+}
+
+// localize подменяет вопрос переводом; без перевода вопрос остаётся на языке по умолчанию
+func (s *QuizService) localize(q model.QuizQuestion, locale string) model.QuizQuestion {
+	if tr, ok := s.translations[locale][q.ID]; ok {
+		return tr
+	}
+	return q
+}
+
+// assignQuestionIDs задаёт ID вопроса и его вариантов: явный id из YAML или хэш содержимого.
+// Хэш не зависит от позиции, поэтому вставка вопроса не меняет ID соседних.
+func assignQuestionIDs(q *model.QuizQuestion, chapterSlug string) error {
+	if q.ID != "" && !quizIDPattern.MatchString(q.ID) {
+		return fmt.Errorf("invalid id %q", q.ID)
+	}
+	if q.ID == "" {
+		q.ID = contentHash(normalizeSpace(q.Question) + "\n" + normalizeSpace(q.Code))
+	}
+	q.ID = chapterSlug + ":" + q.ID
+
+	if err := assignOptionIDs(q.Options); err != nil {
+		return fmt.Errorf("options: %w", err)
+	}
+	if err := assignOptionIDs(q.Lines); err != nil {
+		return fmt.Errorf("lines: %w", err)
+	}
+	return nil
+}
+
+// assignOptionIDs — хэш текста как есть: варианты, различающиеся одним пробелом, получают разные ID
+func assignOptionIDs(options []model.QuizOption) error {
+	seen := make(map[string]bool, len(options))
+	for i := range options {
+		if options[i].ID == "" {
+			options[i].ID = contentHash(options[i].Text)
+		} else if !quizIDPattern.MatchString(options[i].ID) {
+			return fmt.Errorf("invalid id %q", options[i].ID)
+		}
+		if seen[options[i].ID] {
+			return fmt.Errorf("duplicate id %s", options[i].ID)
+		}
+		seen[options[i].ID] = true
+	}
+	return nil
+}
+
+// inheritOptionIDs переносит ID вариантов оригинала в перевод; явный ID перевода должен совпадать
+func inheritOptionIDs(translated, original []model.QuizOption) bool {
+	if len(translated) != len(original) {
+		return false
+	}
+	for i := range translated {
+		if translated[i].ID != "" && translated[i].ID != original[i].ID {
+			return false
+		}
+		translated[i].ID = original[i].ID
+	}
+	return true
+}
+
+// questionRevision — хэш всего, что влияет на ответ; ID и пояснение не учитываются
+func questionRevision(q model.QuizQuestion) string {
+	data, _ := json.Marshal(struct {
+		Type     string
+		Question string
+		Code     string
+		Options  []model.QuizOption
+		Answer   int
+		Answers  []int
+		Accept   []model.QuizTextAccept
+		Lines    []model.QuizOption
+	}{q.Type, normalizeSpace(q.Question), q.Code, q.Options, q.Answer, q.Answers, q.Accept, q.Lines})
+	return contentHash(string(data))
+}
+
+func contentHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:4])
+}
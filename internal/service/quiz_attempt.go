@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrQuizAttemptNotFound = errors.New("quiz attempt not found")
+	ErrQuizAttemptFinished = errors.New("quiz attempt already finished")
+	ErrQuestionAnswered    = errors.New("question already answered")
+	ErrNoQuizQuestions     = errors.New("no questions for quiz")
+)
+
+// StartAttempt фиксирует набор вопросов попытки и возвращает их клиенту
+func (s *QuizService) StartAttempt(ctx context.Context, userID uuid.UUID, chapterSlugs []string, limit int, preferNew bool, locale string) (*model.QuizAttempt, error) {
+	var prefer *uuid.UUID
+	if preferNew {
+		prefer = &userID
+	}
+	return s.createAttempt(ctx, userID, s.GetQuestions(ctx, prefer, chapterSlugs, limit, locale))
+}
+
+func (s *QuizService) createAttempt(ctx context.Context, userID uuid.UUID, questions []model.QuizQuestion) (*model.QuizAttempt, error) {
+	if len(questions) == 0 {
+		return nil, ErrNoQuizQuestions
+	}
+
+	attempt := &model.QuizAttempt{
+		UserID:      userID,
+		QuestionIDs: make([]string, len(questions)),
+		Questions:   questions,
+		Total:       len(questions),
+	}
+	for i, q := range questions {
+		attempt.QuestionIDs[i] = q.ID
+	}
+
+	if err := s.attemptRepo.Create(ctx, attempt); err != nil {
+		return nil, err
+	}
+	return attempt, nil
+}
+
+// AnswerAttempt проверяет и сохраняет ответ; ответить на вопрос попытки можно один раз
+func (s *QuizService) AnswerAttempt(ctx context.Context, userID, attemptID uuid.UUID, questionID string, answers []string, locale string) (model.QuizAnswerResponse, error) {
+	attempt, err := s.openAttempt(ctx, userID, attemptID)
+	if err != nil {
+		return model.QuizAnswerResponse{}, err
+	}
+	if !slices.Contains(attempt.QuestionIDs, questionID) {
+		return model.QuizAnswerResponse{}, ErrQuestionNotFound
+	}
+
+	if err := s.guardExam(ctx, userID, questionID); err != nil {
+		return model.QuizAnswerResponse{}, err
+	}
+	result, err := s.check(questionID, answers, locale)
+	if err != nil {
+		return model.QuizAnswerResponse{}, err
+	}
+
+	err = s.attemptRepo.SaveAnswer(ctx, attempt.ID, userID, model.QuizAttemptAnswer{
+		QuestionID: questionID,
+		Answer:     answers,
+		Correct:    result.Correct,
+		Score:      result.Score,
+	})
+	if errors.Is(err, repository.QuizAnswerExists) {
+		return model.QuizAnswerResponse{}, ErrQuestionAnswered
+	}
+	if err != nil {
+		return model.QuizAnswerResponse{}, err
+	}
+	s.scheduleAnswer(ctx, userID, questionID, result.Score)
+
+	return result, nil
+}
+
+// FinishAttempt подсчитывает итог; вопросы без ответа дают ноль баллов
+func (s *QuizService) FinishAttempt(ctx context.Context, userID, attemptID uuid.UUID) (*model.QuizAttempt, error) {
+	attempt, err := s.openAttempt(ctx, userID, attemptID)
+	if err != nil {
+		return nil, err
+	}
+
+	var total float64
+	for _, a := range attempt.Answers {
+		total += a.Score
+		if a.Correct {
+			attempt.Correct++
+		}
+	}
+	attempt.Score = total / float64(len(attempt.QuestionIDs))
+
+	if err := s.attemptRepo.Finish(ctx, attempt); err != nil {
+		if errors.Is(err, repository.QuizAttemptNotFound) {
+			return nil, ErrQuizAttemptFinished
+		}
+		return nil, err
+	}
+	return attempt, nil
+}
+
+// openAttempt возвращает незавершённую попытку пользователя; чужая попытка выглядит как несуществующая
+func (s *QuizService) openAttempt(ctx context.Context, userID, attemptID uuid.UUID) (*model.QuizAttempt, error) {
+	attempt, err := s.attemptRepo.GetByID(ctx, attemptID)
+	if errors.Is(err, repository.QuizAttemptNotFound) {
+		return nil, ErrQuizAttemptNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if attempt.UserID != userID {
+		return nil, ErrQuizAttemptNotFound
+	}
+	if attempt.FinishedAt != nil {
+		return nil, ErrQuizAttemptFinished
+	}
+	return attempt, nil
+}
+
+// GetStats считает квиз по завершённым попыткам. Балл главы в попытке — доля баллов
+// по её вопросам; попытка по нескольким главам учитывается в каждой из них.
+func (s *QuizService) GetStats(ctx context.Context, userID uuid.UUID) model.QuizStats {
+	attempts, err := s.attemptRepo.ListFinished(ctx, userID)
+	if err != nil {
+		s.log.Error("failed to get quiz attempts for stats", zap.Error(err))
+	}
+
+	stats := model.QuizStats{Attempts: len(attempts)}
+	byChapter := make(map[string]*model.QuizChapterStats, len(s.chapters))
+	for _, ch := range s.chapters {
+		byChapter[ch.Slug] = &model.QuizChapterStats{Slug: ch.Slug, Title: ch.Title}
+	}
+
+	correct := 0
+	for _, attempt := range attempts {
+		if stats.LastAttemptAt == nil {
+			// попытки отсортированы от новых к старым
+			stats.LastAttemptAt = attempt.FinishedAt
+		}
+
+		scores := make(map[string]float64, len(attempt.Answers))
+		for _, a := range attempt.Answers {
+			scores[a.QuestionID] = a.Score
+			stats.AnsweredQuestions++
+			if a.Correct {
+				correct++
+			}
+		}
+
+		sum := make(map[string]float64)
+		count := make(map[string]int)
+		for _, id := range attempt.QuestionIDs {
+			chapter, _, _ := strings.Cut(id, ":")
+			sum[chapter] += scores[id]
+			count[chapter]++
+		}
+		for chapter, n := range count {
+			ch, ok := byChapter[chapter]
+			if !ok {
+				continue
+			}
+			ch.Attempts++
+			if ch.LastAttemptAt == nil {
+				ch.LastAttemptAt = attempt.FinishedAt
+			}
+			ch.BestScore = max(ch.BestScore, sum[chapter]/float64(n))
+		}
+	}
+
+	if stats.AnsweredQuestions > 0 {
+		stats.Accuracy = float64(correct) / float64(stats.AnsweredQuestions)
+	}
+	for _, ch := range s.chapters {
+		stats.Chapters = append(stats.Chapters, *byChapter[ch.Slug])
+	}
+
+	return stats
+}
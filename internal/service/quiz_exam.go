@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// examMaxQuestions — число вопросов экзамена по умолчанию и максимум
+	examMaxQuestions = 20
+	// examReportTTL — сколько отчёт хранится после окончания времени экзамена
+	examReportTTL = 24 * time.Hour
+	// examAnswerGrace — запас на сетевую задержку ответа, отправленного в последнюю секунду
+	examAnswerGrace = 5 * time.Second
+)
+
+var (
+	ErrQuizExamNotFound  = errors.New("quiz exam not found")
+	ErrQuizExamActive    = errors.New("another quiz exam is in progress")
+	ErrQuizExamOver      = errors.New("quiz exam time is over")
+	ErrQuizExamSubmitted = errors.New("quiz exam already submitted")
+	ErrQuestionInExam    = errors.New("question is part of an active exam")
+)
+
+// StartExam фиксирует вопросы и порядок вариантов. Лимит времени —
+// QUIZ_EXAM_QUESTION_TIME на вопрос; клиент получает подписанный токен сессии.
+func (s *QuizService) StartExam(ctx context.Context, userID uuid.UUID, chapterSlugs []string, limit int, locale string) (*model.QuizExamView, error) {
+	active, err := s.activeExam(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, ErrQuizExamActive
+	}
+
+	if limit <= 0 || limit > examMaxQuestions {
+		limit = examMaxQuestions
+	}
+	questions := s.GetQuestions(ctx, nil, chapterSlugs, limit, locale)
+	if len(questions) == 0 {
+		return nil, ErrNoQuizQuestions
+	}
+
+	now := time.Now()
+	session := &model.QuizExamSession{
+		ID:          uuid.NewString(),
+		UserID:      userID,
+		QuestionIDs: make([]string, len(questions)),
+		OptionOrder: make(map[string][]string, len(questions)),
+		StartedAt:   now,
+		ExpiresAt:   now.Add(time.Duration(len(questions)) * s.cfg.ExamQuestionTime),
+	}
+	for i, q := range questions {
+		session.QuestionIDs[i] = q.ID
+		for _, opt := range q.Options {
+			session.OptionOrder[q.ID] = append(session.OptionOrder[q.ID], opt.ID)
+		}
+	}
+
+	if err := s.examRepo.Create(ctx, session, time.Until(session.ExpiresAt)+examReportTTL); err != nil {
+		return nil, err
+	}
+
+	return s.examView(session, locale, map[string][]string{}, nil), nil
+}
+
+// GetExam возвращает состояние экзамена. Если время вышло, а экзамен не сдан,
+// он сдаётся автоматически с сохранёнными ответами.
+func (s *QuizService) GetExam(ctx context.Context, userID uuid.UUID, token, locale string) (*model.QuizExamView, error) {
+	session, err := s.loadExam(ctx, userID, token)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.examRepo.GetReport(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil && time.Now().After(session.ExpiresAt) {
+		return s.submitExam(ctx, session, locale)
+	}
+
+	answers, err := s.examRepo.GetAnswers(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.examView(session, locale, answers, report), nil
+}
+
+// AnswerExam сохраняет ответ без проверки; до сдачи ответ можно менять
+func (s *QuizService) AnswerExam(ctx context.Context, userID uuid.UUID, token, questionID string, answers []string) (*model.QuizExamView, error) {
+	session, err := s.loadExam(ctx, userID, token)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(session.QuestionIDs, questionID) {
+		return nil, ErrQuestionNotFound
+	}
+	if time.Now().After(session.ExpiresAt.Add(examAnswerGrace)) {
+		return nil, ErrQuizExamOver
+	}
+
+	report, err := s.examRepo.GetReport(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if report != nil {
+		return nil, ErrQuizExamSubmitted
+	}
+
+	if err := s.examRepo.SaveAnswer(ctx, session.ID, questionID, answers); err != nil {
+		if errors.Is(err, repository.QuizExamNotFound) {
+			return nil, ErrQuizExamNotFound
+		}
+		return nil, err
+	}
+
+	saved, err := s.examRepo.GetAnswers(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	view := s.examView(session, "", saved, nil)
+	view.Questions = nil
+	return view, nil
+}
+
+// SubmitExam проверяет все ответы разом и возвращает отчёт
+func (s *QuizService) SubmitExam(ctx context.Context, userID uuid.UUID, token, locale string) (*model.QuizExamView, error) {
+	session, err := s.loadExam(ctx, userID, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.submitExam(ctx, session, locale)
+}
+
+func (s *QuizService) submitExam(ctx context.Context, session *model.QuizExamSession, locale string) (*model.QuizExamView, error) {
+	answers, err := s.examRepo.GetAnswers(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &model.QuizExamReport{
+		Total:      len(session.QuestionIDs),
+		Late:       now.After(session.ExpiresAt.Add(examAnswerGrace)),
+		FinishedAt: now,
+	}
+	var total float64
+	for _, id := range session.QuestionIDs {
+		result, err := s.check(id, answers[id], locale)
+		if err != nil {
+			// вопрос удалён из контента после старта экзамена
+			continue
+		}
+		if len(answers[id]) > 0 {
+			report.Answered++
+		}
+		if result.Correct {
+			report.Correct++
+		}
+		total += result.Score
+		report.Results = append(report.Results, model.QuizExamResult{
+			QuestionID:         id,
+			Answer:             answers[id],
+			QuizAnswerResponse: result,
+		})
+	}
+	report.Score = total / float64(report.Total)
+
+	if err := s.examRepo.SaveReport(ctx, session, report); err != nil {
+		switch {
+		case errors.Is(err, repository.QuizExamSubmitted):
+			return nil, ErrQuizExamSubmitted
+		case errors.Is(err, repository.QuizExamNotFound):
+			return nil, ErrQuizExamNotFound
+		}
+		return nil, err
+	}
+	s.recordExam(ctx, session, report)
+
+	return s.examView(session, locale, answers, report), nil
+}
+
+// recordExam сохраняет сданный экзамен как попытку квиза, чтобы он попал в статистику
+func (s *QuizService) recordExam(ctx context.Context, session *model.QuizExamSession, report *model.QuizExamReport) {
+	attempt := &model.QuizAttempt{UserID: session.UserID, QuestionIDs: session.QuestionIDs}
+	err := s.attemptRepo.Create(ctx, attempt)
+	for _, r := range report.Results {
+		if err != nil || len(r.Answer) == 0 {
+			continue
+		}
+		err = s.attemptRepo.SaveAnswer(ctx, attempt.ID, session.UserID, model.QuizAttemptAnswer{
+			QuestionID: r.QuestionID,
+			Answer:     r.Answer,
+			Correct:    r.Correct,
+			Score:      r.Score,
+		})
+	}
+	if err == nil {
+		attempt.Score = report.Score
+		attempt.Correct = report.Correct
+		err = s.attemptRepo.Finish(ctx, attempt)
+	}
+	if err != nil {
+		s.log.Error("failed to record quiz exam attempt", zap.String("exam", session.ID), zap.Error(err))
+	}
+}
+
+// guardExam не даёт проверить вопрос идущего экзамена в обход сдачи
+func (s *QuizService) guardExam(ctx context.Context, userID uuid.UUID, questionID string) error {
+	if s.examRepo == nil {
+		return nil
+	}
+
+	session, err := s.activeExam(ctx, userID)
+	if err != nil || session == nil {
+		return err
+	}
+	if slices.Contains(session.QuestionIDs, questionID) {
+		return ErrQuestionInExam
+	}
+	return nil
+}
+
+// activeExam — несданный экзамен, в который ещё принимаются ответы (до ExpiresAt+examAnswerGrace),
+// или nil; граница совпадает с проверкой в AnswerExam
+func (s *QuizService) activeExam(ctx context.Context, userID uuid.UUID) (*model.QuizExamSession, error) {
+	id, err := s.examRepo.ActiveSession(ctx, userID)
+	if err != nil || id == "" {
+		return nil, err
+	}
+	session, err := s.examRepo.Get(ctx, id)
+	if errors.Is(err, repository.QuizExamNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(session.ExpiresAt.Add(examAnswerGrace)) {
+		return nil, nil
+	}
+
+	report, err := s.examRepo.GetReport(ctx, session.ID)
+	if err != nil || report != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// loadExam проверяет подпись токена и владельца; чужой или поддельный токен выглядит как несуществующий
+func (s *QuizService) loadExam(ctx context.Context, userID uuid.UUID, token string) (*model.QuizExamSession, error) {
+	id, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(s.examSignature(id))) {
+		return nil, ErrQuizExamNotFound
+	}
+
+	session, err := s.examRepo.Get(ctx, id)
+	if errors.Is(err, repository.QuizExamNotFound) {
+		return nil, ErrQuizExamNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, ErrQuizExamNotFound
+	}
+	return session, nil
+}
+
+func (s *QuizService) examSignature(id string) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.ExamSecret))
+	mac.Write([]byte(id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// examView собирает вопросы в порядке сессии с зафиксированным порядком вариантов
+func (s *QuizService) examView(session *model.QuizExamSession, locale string, answers map[string][]string, report *model.QuizExamReport) *model.QuizExamView {
+	view := &model.QuizExamView{
+		Token:            session.ID + "." + s.examSignature(session.ID),
+		Questions:        []model.QuizQuestion{},
+		Answers:          answers,
+		ExpiresAt:        session.ExpiresAt,
+		RemainingSeconds: max(0, int(time.Until(session.ExpiresAt).Seconds())),
+		Report:           report,
+	}
+
+	for _, id := range session.QuestionIDs {
+		q, ok := s.allByID[id]
+		if !ok {
+			continue
+		}
+		q = s.localize(q, locale)
+
+		byID := make(map[string]model.QuizOption, len(q.Options))
+		for _, opt := range q.Options {
+			byID[opt.ID] = opt
+		}
+		ordered := make([]model.QuizOption, 0, len(q.Options))
+		for _, optID := range session.OptionOrder[id] {
+			if opt, ok := byID[optID]; ok {
+				ordered = append(ordered, opt)
+			}
+		}
+		q.Options = ordered
+		view.Questions = append(view.Questions, q)
+	}
+
+	return view
+}
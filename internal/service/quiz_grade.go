@@ -0,0 +1,200 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+)
+
+// prepareQuestion проверяет схему вопроса его типа и дополняет поля для выдачи клиенту
+func (s *QuizService) prepareQuestion(q *model.QuizQuestion) error {
+	if q.Type == "" {
+		q.Type = model.QuizTypeSingle
+	}
+
+	switch q.Type {
+	case model.QuizTypeSingle:
+		if len(q.Options) < 2 {
+			return errors.New("single question needs at least two options")
+		}
+		if q.Answer < 0 || q.Answer >= len(q.Options) {
+			return fmt.Errorf("answer %d is out of range", q.Answer)
+		}
+	case model.QuizTypeMulti:
+		if len(q.Options) < 2 {
+			return errors.New("multi question needs at least two options")
+		}
+		if len(q.Answers) == 0 {
+			return errors.New("multi question needs answers")
+		}
+		seen := make(map[int]bool, len(q.Answers))
+		for _, a := range q.Answers {
+			if a < 0 || a >= len(q.Options) {
+				return fmt.Errorf("answer %d is out of range", a)
+			}
+			if seen[a] {
+				return fmt.Errorf("answer %d is listed twice", a)
+			}
+			seen[a] = true
+		}
+	case model.QuizTypeText:
+		if len(q.Options) > 0 {
+			return errors.New("text question has no options")
+		}
+		if len(q.Accept) == 0 {
+			return errors.New("text question needs accept rules")
+		}
+		for i, rule := range q.Accept {
+			if err := s.prepareAccept(rule); err != nil {
+				return fmt.Errorf("accept %d: %w", i, err)
+			}
+		}
+	case model.QuizTypeOrder:
+		if len(q.Options) > 0 {
+			return errors.New("order question uses lines instead of options")
+		}
+		if len(q.Lines) < 2 {
+			return errors.New("order question needs at least two lines")
+		}
+		q.Options = make([]model.QuizOption, len(q.Lines))
+		copy(q.Options, q.Lines)
+	default:
+		return fmt.Errorf("unknown question type %q", q.Type)
+	}
+
+	if q.Code != "" && q.Type == model.QuizTypeMulti {
+		return errors.New("code is not supported for multi questions")
+	}
+	return nil
+}
+
+func (s *QuizService) prepareAccept(rule model.QuizTextAccept) error {
+	kinds := 0
+	if rule.Exact != "" {
+		kinds++
+	}
+	if rule.Regex != "" {
+		kinds++
+		re, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return err
+		}
+		s.patterns[rule.Regex] = re
+	}
+	if rule.Number != nil {
+		kinds++
+	}
+	if kinds != 1 {
+		return errors.New("exactly one of exact, regex or number is required")
+	}
+	if rule.Tolerance < 0 {
+		return errors.New("tolerance must not be negative")
+	}
+	return nil
+}
+
+// grade оценивает ответ. answers — ID выбранных вариантов, ID строк в порядке
+// пользователя для order или один свободный ответ для text.
+func (s *QuizService) grade(q model.QuizQuestion, answers []string) model.QuizAnswerResponse {
+	resp := model.QuizAnswerResponse{Explanation: q.Explanation}
+
+	switch q.Type {
+	case model.QuizTypeMulti:
+		correct := make(map[string]bool, len(q.Answers))
+		for _, a := range q.Answers {
+			correct[q.Options[a].ID] = true
+			resp.CorrectAnswers = append(resp.CorrectAnswers, q.Options[a].Text)
+			resp.CorrectOptionIDs = append(resp.CorrectOptionIDs, q.Options[a].ID)
+		}
+		// каждый лишний вариант отнимает столько же, сколько даёт верный
+		hits, misses := 0, 0
+		selected := make(map[string]bool, len(answers))
+		for _, a := range answers {
+			if selected[a] {
+				continue
+			}
+			selected[a] = true
+			if correct[a] {
+				hits++
+			} else {
+				misses++
+			}
+		}
+		resp.Correct = hits == len(correct) && misses == 0
+		resp.Score = max(0, float64(hits-misses)/float64(len(correct)))
+	case model.QuizTypeText:
+		var answer string
+		if len(answers) > 0 {
+			answer = answers[0]
+		}
+		resp.Correct = s.accepts(q.Accept, answer)
+		resp.CorrectAnswer = displayAccept(q.Accept)
+	case model.QuizTypeOrder:
+		placed := 0
+		for i, line := range q.Lines {
+			resp.CorrectAnswers = append(resp.CorrectAnswers, line.Text)
+			resp.CorrectOptionIDs = append(resp.CorrectOptionIDs, line.ID)
+			if i < len(answers) && answers[i] == line.ID {
+				placed++
+			}
+		}
+		resp.Correct = placed == len(q.Lines) && len(answers) == len(q.Lines)
+		resp.Score = float64(placed) / float64(len(q.Lines))
+	default:
+		correct := q.Options[q.Answer]
+		resp.CorrectAnswer = correct.Text
+		resp.CorrectOptionIDs = []string{correct.ID}
+		resp.Correct = len(answers) == 1 && answers[0] == correct.ID
+	}
+
+	if resp.Correct {
+		resp.Score = 1
+	}
+	return resp
+}
+
+// accepts — подходит ли свободный ответ хотя бы под одно правило.
+// Пробелы и переводы строк сравниваются как один пробел, в числах допускается запятая.
+func (s *QuizService) accepts(rules []model.QuizTextAccept, answer string) bool {
+	answer = normalizeSpace(answer)
+	for _, rule := range rules {
+		switch {
+		case rule.Exact != "":
+			if answer == normalizeSpace(rule.Exact) {
+				return true
+			}
+		case rule.Regex != "":
+			if s.patterns[rule.Regex].MatchString(answer) {
+				return true
+			}
+		case rule.Number != nil:
+			v, err := strconv.ParseFloat(strings.ReplaceAll(answer, ",", "."), 64)
+			if err == nil && math.Abs(v-*rule.Number) <= rule.Tolerance {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// displayAccept — что показать как верный ответ; у правил только с regex показать нечего
+func displayAccept(rules []model.QuizTextAccept) string {
+	for _, rule := range rules {
+		switch {
+		case rule.Exact != "":
+			return rule.Exact
+		case rule.Number != nil:
+			return strconv.FormatFloat(*rule.Number, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/google/uuid"
+)
+
+// LessonQuiz возвращает мини-квиз урока; урок без вопросов даёт пустой список
+func (s *QuizService) LessonQuiz(ctx context.Context, userID *uuid.UUID, chapterSlug, lessonSlug, locale string) (*model.LessonQuiz, error) {
+	if err := s.theory.lessonExists(chapterSlug, lessonSlug); err != nil {
+		return nil, err
+	}
+
+	quiz := &model.LessonQuiz{
+		ChapterSlug: chapterSlug,
+		LessonSlug:  lessonSlug,
+		Questions:   s.presentLesson(chapterSlug, lessonSlug, locale),
+		PassScore:   s.cfg.LessonPassScore,
+	}
+	if userID != nil {
+		passed, err := s.LessonQuizPassed(ctx, *userID, chapterSlug, lessonSlug)
+		if err != nil {
+			return nil, err
+		}
+		quiz.Passed = &passed
+	}
+	return quiz, nil
+}
+
+// StartLessonAttempt начинает попытку по всем вопросам урока; ответы идут через обычные ручки попыток
+func (s *QuizService) StartLessonAttempt(ctx context.Context, userID uuid.UUID, chapterSlug, lessonSlug, locale string) (*model.QuizAttempt, error) {
+	if err := s.theory.lessonExists(chapterSlug, lessonSlug); err != nil {
+		return nil, err
+	}
+	return s.createAttempt(ctx, userID, s.presentLesson(chapterSlug, lessonSlug, locale))
+}
+
+// LessonQuizPassed: доля вопросов урока, последний ответ на которые верен, не ниже
+// QUIZ_LESSON_PASS_SCORE. Урок без вопросов считается пройденным.
+func (s *QuizService) LessonQuizPassed(ctx context.Context, userID uuid.UUID, chapterSlug, lessonSlug string) (bool, error) {
+	ids := s.lessonQuestions[LessonRef(chapterSlug, lessonSlug)]
+	if len(ids) == 0 {
+		return true, nil
+	}
+
+	history, err := s.attemptRepo.GetQuestionHistory(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	correct := 0
+	for _, id := range ids {
+		if history[id] {
+			correct++
+		}
+	}
+	return float64(correct)/float64(len(ids)) >= s.cfg.LessonPassScore, nil
+}
+
+// presentLesson — вопросы урока в порядке quiz.yaml с перемешанными вариантами
+func (s *QuizService) presentLesson(chapterSlug, lessonSlug, locale string) []model.QuizQuestion {
+	ids := s.lessonQuestions[LessonRef(chapterSlug, lessonSlug)]
+	questions := make([]model.QuizQuestion, 0, len(ids))
+	for _, id := range ids {
+		questions = append(questions, s.present(s.allByID[id], locale))
+	}
+	return questions
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Параметры SM-2: начальный и минимальный коэффициент лёгкости
+const (
+	sm2InitialEase = 2.5
+	sm2MinEase     = 1.3
+)
+
+// ReviewQueue возвращает карточки, срок которых наступает до конца текущего дня (UTC).
+// Карточка изменённого вопроса считается новой и попадает в очередь сразу.
+func (s *QuizService) ReviewQueue(ctx context.Context, userID uuid.UUID, limit int, locale string) ([]model.QuizReviewItem, error) {
+	cards, err := s.reviewRepo.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	endOfDay := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+	items := []model.QuizReviewItem{}
+	for _, card := range cards {
+		q, ok := s.allByID[card.QuestionID]
+		if !ok {
+			continue
+		}
+		if card.Revision != q.Revision {
+			card.DueAt = now
+			card.Repetitions = 0
+		}
+		if !card.DueAt.Before(endOfDay) {
+			continue
+		}
+		items = append(items, model.QuizReviewItem{
+			Question:    s.present(q, locale),
+			DueAt:       card.DueAt,
+			Repetitions: card.Repetitions,
+		})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DueAt.Before(items[j].DueAt)
+	})
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+// ReviewAnswer проверяет ответ и переносит следующее повторение вопроса
+func (s *QuizService) ReviewAnswer(ctx context.Context, userID uuid.UUID, questionID string, answers []string, locale string) (model.QuizReviewResponse, error) {
+	if err := s.guardExam(ctx, userID, questionID); err != nil {
+		return model.QuizReviewResponse{}, err
+	}
+	result, err := s.check(questionID, answers, locale)
+	if err != nil {
+		return model.QuizReviewResponse{}, err
+	}
+
+	card, err := s.schedule(ctx, userID, questionID, result.Score)
+	if err != nil {
+		return model.QuizReviewResponse{}, err
+	}
+
+	return model.QuizReviewResponse{
+		QuizAnswerResponse: result,
+		DueAt:              card.DueAt,
+		IntervalDays:       card.IntervalDays,
+	}, nil
+}
+
+// schedule обновляет карточку вопроса по баллу ответа. Если вопрос изменился
+// с прошлого повторения, расписание начинается заново.
+func (s *QuizService) schedule(ctx context.Context, userID uuid.UUID, questionID string, score float64) (*model.QuizReviewCard, error) {
+	q, ok := s.allByID[questionID]
+	if !ok {
+		return nil, ErrQuestionNotFound
+	}
+
+	card, err := s.reviewRepo.Get(ctx, userID, questionID)
+	switch {
+	case errors.Is(err, repository.QuizReviewNotFound) || (err == nil && card.Revision != q.Revision):
+		card = &model.QuizReviewCard{QuestionID: questionID, Ease: sm2InitialEase}
+	case err != nil:
+		return nil, err
+	}
+
+	card.Revision = q.Revision
+	sm2(card, reviewQuality(score), time.Now())
+
+	if err := s.reviewRepo.Save(ctx, userID, card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// scheduleAnswer ставит отвеченный вопрос в очередь повторения; сбой не мешает ответу
+func (s *QuizService) scheduleAnswer(ctx context.Context, userID uuid.UUID, questionID string, score float64) {
+	if _, err := s.schedule(ctx, userID, questionID, score); err != nil {
+		s.log.Error("failed to schedule quiz review", zap.String("question", questionID), zap.Error(err))
+	}
+}
+
+// reviewQuality переводит балл ответа в оценку SM-2 от 0 до 5; ниже 3 — забыто
+func reviewQuality(score float64) int {
+	return int(math.Round(score * 5))
+}
+
+// sm2 — классический алгоритм SuperMemo 2: интервалы 1, 6, затем interval*ease дней
+func sm2(card *model.QuizReviewCard, quality int, now time.Time) {
+	if quality >= 3 {
+		switch card.Repetitions {
+		case 0:
+			card.IntervalDays = 1
+		case 1:
+			card.IntervalDays = 6
+		default:
+			card.IntervalDays = int(math.Round(float64(card.IntervalDays) * card.Ease))
+		}
+		card.Repetitions++
+	} else {
+		card.Repetitions = 0
+		card.IntervalDays = 1
+	}
+
+	miss := float64(5 - quality)
+	card.Ease = max(sm2MinEase, card.Ease+0.1-miss*(0.08+miss*0.02))
+	card.ReviewedAt = now
+	card.DueAt = now.AddDate(0, 0, card.IntervalDays)
+}
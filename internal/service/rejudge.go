@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/config"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrRejudgeInProgress   = errors.New("rejudge already in progress")
+	ErrRejudgeReasonEmpty  = errors.New("rejudge reason is required")
+	ErrRejudgeKindNotFound = errors.New("unknown rejudge kind")
+	ErrRejudgeJobNotFound  = errors.New("rejudge job not found")
+)
+
+// rejudgeJobTTL — сколько завершённая фоновая перепроверка доступна для просмотра
+const rejudgeJobTTL = 24 * time.Hour
+
+// sandboxInternalError — ответ песочницы при сбое инфраструктуры, а не кода пользователя
+const sandboxInternalError = "internal error"
+
+type RejudgeService struct {
+	log            *zap.Logger
+	taskService    *TaskService
+	projectService *ProjectService
+	sandboxService *SandboxService
+	submissionRepo repository.SubmissionRepository
+	rejudgeRepo    repository.RejudgeRepository
+	interval       time.Duration
+	jobsMu         sync.Mutex
+	jobs           map[uuid.UUID]*model.RejudgeJob
+}
+
+func NewRejudgeService(
+	log *zap.Logger,
+	taskService *TaskService,
+	projectService *ProjectService,
+	sandboxService *SandboxService,
+	submissionRepo repository.SubmissionRepository,
+	rejudgeRepo repository.RejudgeRepository,
+	rejudgeCfg config.RejudgeConfig,
+) *RejudgeService {
+	return &RejudgeService{
+		log:            log,
+		taskService:    taskService,
+		projectService: projectService,
+		sandboxService: sandboxService,
+		submissionRepo: submissionRepo,
+		rejudgeRepo:    rejudgeRepo,
+		interval:       rejudgeCfg.Interval,
+		jobs:           make(map[uuid.UUID]*model.RejudgeJob),
+	}
+}
+
+// Rejudge перезапускает сохранённые посылки против текущих тестов.
+// Одновременно во всех процессах (серверах и cmd/rejudge) выполняется только одна
+// перепроверка, запуски песочницы разнесены на interval, чтобы не вытеснять живые посылки.
+// При DryRun результаты не сохраняются, возвращается только отчёт.
+func (s *RejudgeService) Rejudge(ctx context.Context, req model.RejudgeRequest) (model.RejudgeReport, error) {
+	run, unlock, err := s.prepare(ctx, req)
+	if err != nil {
+		return model.RejudgeReport{}, err
+	}
+	defer unlock()
+
+	return s.rejudge(ctx, req, run)
+}
+
+// Start запускает перепроверку в фоне со своим контекстом: обрыв запроса не прерывает её
+// на середине. Ход и отчёт возвращает Job.
+func (s *RejudgeService) Start(ctx context.Context, req model.RejudgeRequest) (*model.RejudgeJob, error) {
+	run, unlock, err := s.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &model.RejudgeJob{
+		ID:          uuid.New(),
+		Status:      model.RejudgeRunning,
+		Kind:        req.Kind,
+		ChapterSlug: req.ChapterSlug,
+		TaskSlug:    req.TaskSlug,
+		StartedAt:   time.Now(),
+	}
+
+	s.jobsMu.Lock()
+	for id, j := range s.jobs {
+		if j.FinishedAt != nil && time.Since(*j.FinishedAt) > rejudgeJobTTL {
+			delete(s.jobs, id)
+		}
+	}
+	s.jobs[job.ID] = job
+	started := *job
+	s.jobsMu.Unlock()
+
+	go func() {
+		defer unlock()
+
+		report, err := s.rejudge(context.Background(), req, run)
+
+		s.jobsMu.Lock()
+		defer s.jobsMu.Unlock()
+		finishedAt := time.Now()
+		job.FinishedAt = &finishedAt
+		job.Report = &report
+		job.Status = model.RejudgeDone
+		if err != nil {
+			job.Status = model.RejudgeFailed
+			job.Error = err.Error()
+		}
+	}()
+
+	return &started, nil
+}
+
+func (s *RejudgeService) Job(id uuid.UUID) (*model.RejudgeJob, error) {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrRejudgeJobNotFound
+	}
+	result := *job
+	return &result, nil
+}
+
+// prepare проверяет запрос и захватывает общую блокировку; при успехе её снимает вызывающий через unlock
+func (s *RejudgeService) prepare(ctx context.Context, req model.RejudgeRequest) (func(ctx context.Context, code string) model.SubmitResult, func(), error) {
+	if req.Reason == "" && !req.DryRun {
+		return nil, nil, ErrRejudgeReasonEmpty
+	}
+
+	run, err := s.judgeFunc(req.Kind, req.ChapterSlug, req.TaskSlug)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	unlock, ok, err := s.rejudgeRepo.TryLock(ctx)
+	if err != nil {
+		s.log.Error("failed to acquire rejudge lock", zap.Error(err))
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, ErrRejudgeInProgress
+	}
+	return run, unlock, nil
+}
+
+func (s *RejudgeService) rejudge(ctx context.Context, req model.RejudgeRequest, run func(ctx context.Context, code string) model.SubmitResult) (model.RejudgeReport, error) {
+	submissions, err := s.submissionRepo.ListByTask(ctx, req.ChapterSlug, req.TaskSlug)
+	if err != nil {
+		s.log.Error("failed to list submissions for rejudge", zap.Error(err))
+		return model.RejudgeReport{}, err
+	}
+
+	report := model.RejudgeReport{
+		Kind:        req.Kind,
+		ChapterSlug: req.ChapterSlug,
+		TaskSlug:    req.TaskSlug,
+		DryRun:      req.DryRun,
+		Submissions: []model.RejudgedSubmission{},
+	}
+
+	solvedBefore := make(map[uuid.UUID]bool)
+	solvedAfter := make(map[uuid.UUID]bool)
+	seen := make(map[uuid.UUID]bool)
+
+	for _, sub := range submissions {
+		solvedBefore[sub.UserID] = solvedBefore[sub.UserID] || sub.Passed
+
+		// submissions отсортированы по user_id и created_at DESC — первая посылка пользователя самая свежая
+		latest := !seen[sub.UserID]
+		seen[sub.UserID] = true
+		if !req.All && !latest {
+			solvedAfter[sub.UserID] = solvedAfter[sub.UserID] || sub.Passed
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if report.Total > 0 && s.interval > 0 {
+			select {
+			case <-ctx.Done():
+				return report, ctx.Err()
+			case <-time.After(s.interval):
+			}
+		}
+
+		result := run(ctx, sub.Code)
+		item := model.RejudgedSubmission{
+			SubmissionID: sub.ID,
+			UserID:       sub.UserID,
+			OldPassed:    sub.Passed,
+			NewPassed:    result.Passed,
+		}
+		report.Total++
+
+		if result.Error == sandboxInternalError {
+			// сбой песочницы не должен менять вердикт
+			item.NewPassed = sub.Passed
+			item.Error = result.Error
+			report.Errors++
+			report.Submissions = append(report.Submissions, item)
+			solvedAfter[sub.UserID] = solvedAfter[sub.UserID] || sub.Passed
+			continue
+		}
+
+		solvedAfter[sub.UserID] = solvedAfter[sub.UserID] || result.Passed
+		if result.Passed != sub.Passed {
+			report.Changed++
+		}
+
+		if !req.DryRun {
+			err := s.rejudgeRepo.Apply(ctx, &model.Rejudge{
+				SubmissionID: sub.ID,
+				Reason:       req.Reason,
+				OldPassed:    sub.Passed,
+				NewPassed:    result.Passed,
+				OldResult:    sub.Result,
+				NewResult:    result,
+			})
+			if err != nil {
+				s.log.Error("failed to apply rejudge", zap.String("submission_id", sub.ID.String()), zap.Error(err))
+				return report, err
+			}
+		}
+
+		report.Submissions = append(report.Submissions, item)
+	}
+
+	for userID, before := range solvedBefore {
+		after := solvedAfter[userID]
+		switch {
+		case !before && after:
+			report.GainedSolved++
+		case before && !after:
+			report.LostSolved++
+		}
+	}
+
+	s.log.Info("rejudge finished",
+		zap.String("kind", req.Kind),
+		zap.String("chapter", req.ChapterSlug),
+		zap.String("task", req.TaskSlug),
+		zap.Bool("dry_run", req.DryRun),
+		zap.Int("total", report.Total),
+		zap.Int("changed", report.Changed),
+		zap.Int("gained_solved", report.GainedSolved),
+		zap.Int("lost_solved", report.LostSolved),
+	)
+
+	return report, nil
+}
+
+func (s *RejudgeService) judgeFunc(kind, chapterSlug, taskSlug string) (func(ctx context.Context, code string) model.SubmitResult, error) {
+	switch kind {
+	case model.RejudgeKindTask:
+		testFile, err := s.taskService.GetTestFile(chapterSlug, taskSlug)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, code string) model.SubmitResult {
+			return s.sandboxService.RunTask(ctx, code, testFile)
+		}, nil
+	case model.RejudgeKindProject:
+		if _, err := s.projectService.BuildSandboxFiles(chapterSlug, taskSlug, ""); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, code string) model.SubmitResult {
+			files, err := s.projectService.BuildSandboxFiles(chapterSlug, taskSlug, code)
+			if err != nil {
+				return model.SubmitResult{Error: sandboxInternalError}
+			}
+			return s.sandboxService.RunProject(ctx, files)
+		}, nil
+	default:
+		return nil, ErrRejudgeKindNotFound
+	}
+}
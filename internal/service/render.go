@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/markdown"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"go.uber.org/zap"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// RenderService отдаёт контент уроков, задач и шагов в HTML вместо markdown (?format=html)
+type RenderService struct {
+	log      *zap.Logger
+	renderer *markdown.Renderer
+}
+
+func NewRenderService(log *zap.Logger) *RenderService {
+	return &RenderService{log: log, renderer: markdown.NewRenderer()}
+}
+
+// ParseFormat проверяет значение ?format=; пустое значение означает markdown
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case "", FormatMarkdown:
+		return FormatMarkdown, nil
+	case FormatHTML:
+		return FormatHTML, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// RenderLesson заменяет Content урока отрисованным HTML
+func (s *RenderService) RenderLesson(lesson *model.Lesson) error {
+	rendered, err := s.render(lesson.Content)
+	if err != nil {
+		return err
+	}
+	lesson.Rendered = rendered
+	lesson.Content = ""
+	return nil
+}
+
+// RenderTask заменяет Description задачи отрисованным HTML
+func (s *RenderService) RenderTask(task *model.Task) error {
+	rendered, err := s.render(task.Description)
+	if err != nil {
+		return err
+	}
+	task.Rendered = rendered
+	task.Description = ""
+	return nil
+}
+
+// RenderStep заменяет Description шага проекта отрисованным HTML
+func (s *RenderService) RenderStep(step *model.ProjectStep) error {
+	rendered, err := s.render(step.Description)
+	if err != nil {
+		return err
+	}
+	step.Rendered = rendered
+	step.Description = ""
+	return nil
+}
+
+func (s *RenderService) render(source string) (*model.RenderedContent, error) {
+	rendered, err := s.renderer.Render(source)
+	if err != nil {
+		s.log.Error("failed to render markdown", zap.Error(err))
+		return nil, err
+	}
+	return &rendered, nil
+}
@@ -63,10 +63,50 @@ func (s *SandboxService) RunProject(ctx context.Context, files map[string]string
 }
 
 func (s *SandboxService) run(ctx context.Context, files map[string]string) model.SubmitResult {
+	out, errMsg := s.exec(ctx, files, []string{"go", "test", "-v", "-json", "-count=1", "./..."})
+	if errMsg != "" {
+		return model.SubmitResult{Error: errMsg}
+	}
+	return s.parseTestOutput(out.stdout, out.stderr)
+}
+
+// RunProgram выполняет go run . и возвращает вывод программы.
+// Ошибки компиляции и паники попадают в Error, как их выводит go run.
+func (s *SandboxService) RunProgram(ctx context.Context, files map[string]string) model.RunResult {
+	out, errMsg := s.exec(ctx, files, []string{"go", "run", "."})
+	if errMsg != "" {
+		return model.RunResult{Error: errMsg}
+	}
+	return model.RunResult{
+		Output:   truncateOutput(out.stdout),
+		Error:    truncateOutput(out.stderr),
+		ExitCode: out.exitCode,
+	}
+}
+
+// maxRunOutput — сколько байт вывода программы возвращается клиенту
+const maxRunOutput = 64 << 10
+
+func truncateOutput(out string) string {
+	if len(out) <= maxRunOutput {
+		return out
+	}
+	return out[:maxRunOutput] + "\n... output truncated"
+}
+
+type execOutput struct {
+	stdout   string
+	stderr   string
+	exitCode int64
+}
+
+// exec запускает cmd в контейнере с files в /sandbox.
+// Непустая строка ошибки означает сбой инфраструктуры или таймаут, а не ошибку кода пользователя.
+func (s *SandboxService) exec(ctx context.Context, files map[string]string, cmd []string) (execOutput, string) {
 	tarBuf, err := s.createTarArchive(files)
 	if err != nil {
 		s.log.Error("failed to create tar archive", zap.Error(err))
-		return model.SubmitResult{Error: "internal error"}
+		return execOutput{}, "internal error"
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, s.timeOut)
@@ -85,48 +125,50 @@ func (s *SandboxService) run(ctx context.Context, files map[string]string) model
 
 	resp, err := s.docker.ContainerCreate(ctx, &container.Config{
 		Image:      s.image,
-		Cmd:        []string{"go", "test", "-v", "-json", "-count=1", "./..."},
+		Cmd:        cmd,
 		WorkingDir: "/sandbox",
 		Env:        []string{"GOCACHE=/root/.cache/go/build"},
 	}, hostCfg, nil, nil, "")
 	if err != nil {
 		s.log.Error("container create failed", zap.Error(err))
-		return model.SubmitResult{Error: "internal error"}
+		return execOutput{}, "internal error"
 	}
 
 	defer s.docker.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
 
 	if err := s.docker.CopyToContainer(ctx, resp.ID, "/sandbox", tarBuf, container.CopyToContainerOptions{}); err != nil {
 		s.log.Error("copy to container failed", zap.Error(err))
-		return model.SubmitResult{Error: "internal error"}
+		return execOutput{}, "internal error"
 	}
 
 	if err := s.docker.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
 		s.log.Error("container start failed", zap.Error(err))
-		return model.SubmitResult{Error: "internal error"}
+		return execOutput{}, "internal error"
 	}
 
+	var exitCode int64
 	statusCh, errCh := s.docker.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
 	select {
 	case err := <-errCh:
 		if err != nil {
 			s.log.Error("container wait failed", zap.Error(err))
-			return model.SubmitResult{Error: "execution timeout"}
+			return execOutput{}, "execution timeout"
 		}
-	case <-statusCh:
+	case status := <-statusCh:
+		exitCode = status.StatusCode
 	}
 
 	out, err := s.docker.ContainerLogs(ctx, resp.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
 	if err != nil {
 		s.log.Error("container logs failed", zap.Error(err))
-		return model.SubmitResult{Error: "internal error"}
+		return execOutput{}, "internal error"
 	}
 	defer out.Close()
 
 	var stdout, stderr bytes.Buffer
 	stdcopy.StdCopy(&stdout, &stderr, out)
 
-	return s.parseTestOutput(stdout.String(), stderr.String())
+	return execOutput{stdout: stdout.String(), stderr: stderr.String(), exitCode: exitCode}, ""
 }
 
 type goTestEvent struct {
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/search"
+	"go.uber.org/zap"
+)
+
+type SearchService struct {
+	log     *zap.Logger
+	theory  *TheoryService
+	task    *TaskService
+	quiz    *QuizService
+	project *ProjectService
+
+	mu      sync.RWMutex
+	indexes map[string]*search.Index
+}
+
+func NewSearchService(log *zap.Logger, theory *TheoryService, task *TaskService, quiz *QuizService, project *ProjectService) *SearchService {
+	s := &SearchService{
+		log:     log,
+		theory:  theory,
+		task:    task,
+		quiz:    quiz,
+		project: project,
+	}
+	s.Rebuild(context.Background())
+	return s
+}
+
+// Rebuild заново строит индексы по текущему контенту. Контент встроен в бинарник
+// и загружается только при старте, поэтому индекс строится один раз в конструкторе
+func (s *SearchService) Rebuild(ctx context.Context) {
+	indexes := make(map[string]*search.Index, len(model.SupportedLocales))
+	for _, locale := range model.SupportedLocales {
+		indexes[locale] = search.NewIndex(s.collectDocuments(ctx, locale))
+	}
+
+	s.mu.Lock()
+	s.indexes = indexes
+	s.mu.Unlock()
+
+	s.log.Info("search index built", zap.Int("documents", indexes[model.DefaultLocale].Len()))
+}
+
+// Search ищет по урокам, задачам, вопросам и шагам проектов. Пустой types — все типы.
+func (s *SearchService) Search(query string, types []string, locale string, limit int) model.SearchResponse {
+	s.mu.RLock()
+	idx, ok := s.indexes[locale]
+	if !ok {
+		idx = s.indexes[model.DefaultLocale]
+	}
+	s.mu.RUnlock()
+
+	var filter func(search.Document) bool
+	if len(types) > 0 {
+		allowed := make(map[string]bool, len(types))
+		for _, t := range types {
+			allowed[strings.TrimSpace(t)] = true
+		}
+		filter = func(d search.Document) bool {
+			return allowed[d.Type]
+		}
+	}
+
+	hits := idx.Search(query, filter, limit)
+	results := make([]model.SearchResult, len(hits))
+	for i, h := range hits {
+		results[i] = model.SearchResult{
+			Type:        h.Document.Type,
+			ChapterSlug: h.Document.ChapterSlug,
+			Slug:        h.Document.Slug,
+			Title:       h.Document.Title,
+			Snippet:     h.Snippet,
+			Score:       h.Score,
+			Locale:      h.Document.Locale,
+		}
+	}
+
+	return model.SearchResponse{Query: query, Results: results}
+}
+
+func (s *SearchService) collectDocuments(ctx context.Context, locale string) []search.Document {
+	var docs []search.Document
+
+	for _, ch := range s.theory.ListChapters(ctx, nil, locale) {
+		for _, l := range ch.Lessons {
+			lesson, err := s.theory.GetLesson(ctx, ch.Slug, l.Slug, nil, locale)
+			if err != nil {
+				continue
+			}
+			docs = append(docs, search.Document{
+				Type:        model.SearchTypeLesson,
+				ChapterSlug: ch.Slug,
+				Slug:        lesson.Slug,
+				Title:       lesson.Title,
+				Body:        lesson.Content,
+				Locale:      lesson.Locale,
+			})
+		}
+	}
+
+	for _, ch := range s.task.ListChapters(ctx, nil, locale) {
+		for _, t := range ch.Tasks {
+			task, err := s.task.GetTask(ctx, ch.Slug, t.Slug, nil, locale)
+			if err != nil {
+				continue
+			}
+			docs = append(docs, search.Document{
+				Type:        model.SearchTypeTask,
+				ChapterSlug: ch.Slug,
+				Slug:        task.Slug,
+				Title:       task.Title,
+				Body:        task.Description,
+				Locale:      task.Locale,
+			})
+		}
+	}
+
+	for _, q := range s.quiz.GetQuestions(ctx, nil, nil, 0, locale) {
+		options := make([]string, len(q.Options))
+		for i, opt := range q.Options {
+			options[i] = opt.Text
+		}
+		docs = append(docs, search.Document{
+			Type:        model.SearchTypeQuiz,
+			ChapterSlug: q.ChapterSlug,
+			Slug:        q.ID,
+			Title:       q.Question,
+			Body:        strings.Join(options, "\n"),
+			Locale:      q.Locale,
+		})
+	}
+
+	for _, p := range s.project.ListProjects(ctx, nil, locale) {
+		for _, st := range p.Steps {
+			step, err := s.project.GetStep(ctx, p.Slug, st.Slug, nil, locale)
+			if err != nil {
+				continue
+			}
+			docs = append(docs, search.Document{
+				Type:        model.SearchTypeStep,
+				ChapterSlug: p.Slug,
+				Slug:        step.Slug,
+				Title:       step.Title,
+				Body:        step.Description,
+				Locale:      step.Locale,
+			})
+		}
+	}
+
+	return docs
+}
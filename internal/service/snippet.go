@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/markdown"
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"go.uber.org/zap"
+	"golang.org/x/tools/imports"
+)
+
+var (
+	ErrSnippetNotFound    = errors.New("snippet not found")
+	ErrSnippetNotRunnable = errors.New("snippet is not runnable")
+)
+
+const (
+	// snippetNoRun — пометка в info-строке блока: ```go no-run
+	snippetNoRun = "no-run"
+	// snippetIDAttr задаёт явный ID блока: ```go id=counter
+	snippetIDAttr = "id="
+)
+
+type SnippetService struct {
+	log            *zap.Logger
+	theoryService  *TheoryService
+	sandboxService *SandboxService
+}
+
+func NewSnippetService(log *zap.Logger, theoryService *TheoryService, sandboxService *SandboxService) *SnippetService {
+	return &SnippetService{
+		log:            log,
+		theoryService:  theoryService,
+		sandboxService: sandboxService,
+	}
+}
+
+// Run выполняет пример из урока в песочнице. Если code пустой, запускается исходный код примера.
+// Неполный фрагмент дополняется до программы в пакете main.
+func (s *SnippetService) Run(ctx context.Context, chapterSlug, lessonSlug, snippetID, code, locale string) (model.RunResult, error) {
+	snippet, err := s.theoryService.GetSnippet(chapterSlug, lessonSlug, snippetID, locale)
+	if err != nil {
+		return model.RunResult{}, err
+	}
+	if !snippet.Runnable {
+		return model.RunResult{}, ErrSnippetNotRunnable
+	}
+
+	if code == "" {
+		code = snippet.Code
+	}
+
+	files := map[string]string{
+		"go.mod":  "module snippet\n\ngo 1.25\n",
+		"main.go": wrapSnippet(code),
+	}
+	return s.sandboxService.RunProgram(ctx, files), nil
+}
+
+// extractSnippets собирает блоки ```go урока. ID берётся из id=, иначе из хэша кода,
+// поэтому не меняется при перестановке блоков и правке соседнего текста.
+func extractSnippets(content string) []model.Snippet {
+	var snippets []model.Snippet
+	seen := make(map[string]int)
+
+	for _, block := range markdown.FencedBlocks(content) {
+		if block.Language != "go" {
+			continue
+		}
+
+		snippet := model.Snippet{Index: block.Index, Code: block.Code, Runnable: true}
+		for _, attr := range block.Attrs {
+			switch {
+			case attr == snippetNoRun:
+				snippet.Runnable = false
+			case strings.HasPrefix(attr, snippetIDAttr):
+				snippet.ID = strings.TrimPrefix(attr, snippetIDAttr)
+			}
+		}
+		if snippet.ID == "" {
+			sum := sha256.Sum256([]byte(strings.TrimSpace(block.Code)))
+			snippet.ID = hex.EncodeToString(sum[:4])
+		}
+
+		seen[snippet.ID]++
+		if n := seen[snippet.ID]; n > 1 {
+			snippet.ID += "-" + strconv.Itoa(n)
+		}
+		snippets = append(snippets, snippet)
+	}
+
+	return snippets
+}
+
+// wrapSnippet дополняет фрагмент до программы:
+//   - файл с объявлением пакета переводится в package main;
+//   - объявления без пакета получают package main и пустой main, если его нет;
+//   - остальное считается телом main.
+//
+// Недостающие импорты добавляются через goimports. Если фрагмент не разбирается,
+// он всё равно оборачивается в main, и ошибку покажет компилятор.
+func wrapSnippet(code string) string {
+	src := "package main\n\nfunc main() {\n" + code + "\n}\n"
+
+	fset := token.NewFileSet()
+	if f, err := parser.ParseFile(fset, "main.go", code, parser.PackageClauseOnly); err == nil {
+		src = code
+		if f.Name.Name != "main" {
+			start := fset.Position(f.Name.Pos()).Offset
+			end := fset.Position(f.Name.End()).Offset
+			src = code[:start] + "main" + code[end:]
+		}
+	} else {
+		decls := "package main\n\n" + code
+		if f, err := parser.ParseFile(token.NewFileSet(), "main.go", decls, parser.SkipObjectResolution); err == nil {
+			src = decls
+			if !hasMain(f) {
+				src += "\nfunc main() {}\n"
+			}
+		}
+	}
+
+	processed, err := imports.Process("main.go", []byte(src), &imports.Options{Comments: true, TabIndent: true, TabWidth: 8})
+	if err != nil {
+		return src
+	}
+	return string(processed)
+}
+
+func hasMain(f *ast.File) bool {
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" {
+			return true
+		}
+	}
+	return false
+}
@@ -11,13 +11,15 @@ type StatsService struct {
 	theory  *TheoryService
 	task    *TaskService
 	project *ProjectService
+	quiz    *QuizService
 }
 
-func NewStatsService(theory *TheoryService, task *TaskService, project *ProjectService) *StatsService {
+func NewStatsService(theory *TheoryService, task *TaskService, project *ProjectService, quiz *QuizService) *StatsService {
 	return &StatsService{
 		theory:  theory,
 		task:    task,
 		project: project,
+		quiz:    quiz,
 	}
 }
 
@@ -26,5 +28,48 @@ func (s *StatsService) GetUserStats(ctx context.Context, userID uuid.UUID) model
 		Theory:   s.theory.GetStats(ctx, userID),
 		Tasks:    s.task.GetStats(ctx, userID),
 		Projects: s.project.GetStats(ctx, userID),
+		Quiz:     s.quiz.GetStats(ctx, userID),
 	}
 }
+
+// GetPublicProfile собирает публичную страницу; разделы включаются настройками приватности
+func (s *StatsService) GetPublicProfile(ctx context.Context, user *model.User) model.PublicProfile {
+	profile := model.PublicProfile{
+		Handle:      user.Handle,
+		Name:        user.Name,
+		Picture:     user.Picture,
+		MemberSince: user.CreatedAt,
+	}
+	privacy := user.Privacy
+
+	var tasks model.TasksStats
+	if privacy.ShowStats || privacy.ShowChapters {
+		tasks = s.task.GetStats(ctx, user.ID)
+	}
+
+	var completed []model.PublicProject
+	if privacy.ShowStats || privacy.ShowProjects {
+		completed = []model.PublicProject{}
+		for _, p := range s.project.GetStats(ctx, user.ID).Projects {
+			if p.Total > 0 && p.Solved == p.Total {
+				completed = append(completed, model.PublicProject{Slug: p.Slug, Title: p.Title})
+			}
+		}
+	}
+
+	if privacy.ShowStats {
+		profile.Stats = &model.PublicStats{
+			CompletedLessons:  s.theory.GetStats(ctx, user.ID).CompletedLessons,
+			SolvedTasks:       tasks.SolvedTasks,
+			CompletedProjects: len(completed),
+		}
+	}
+	if privacy.ShowChapters {
+		profile.Chapters = tasks.Chapters
+	}
+	if privacy.ShowProjects {
+		profile.CompletedProjects = completed
+	}
+
+	return profile
+}
@@ -15,6 +15,7 @@ type SubmissionService struct {
 	projectService *ProjectService
 	sandboxService *SandboxService
 	submissionRepo repository.SubmissionRepository
+	prereq         *PrerequisiteService
 }
 
 func NewSubmissionService(
@@ -23,8 +24,10 @@ func NewSubmissionService(
 	sandboxService *SandboxService,
 	submissionRepo repository.SubmissionRepository,
 	projectService *ProjectService,
+	prereq *PrerequisiteService,
 ) *SubmissionService {
 	return &SubmissionService{
+		prereq:         prereq,
 		log:            log,
 		taskService:    taskService,
 		sandboxService: sandboxService,
@@ -39,6 +42,10 @@ func (s *SubmissionService) Submit(ctx context.Context, userID uuid.UUID, chapte
 		return model.SubmitResult{}, err
 	}
 
+	if err := s.prereq.CheckUnlocked(ctx, userID, TaskRef(chapterSlug, taskSlug)); err != nil {
+		return model.SubmitResult{}, err
+	}
+
 	result := s.sandboxService.RunTask(ctx, code, testFile)
 
 	submission := &model.Submission{
@@ -64,6 +71,10 @@ func (s *SubmissionService) SubmitProject(ctx context.Context, userID uuid.UUID,
 		return model.SubmitResult{}, err
 	}
 
+	if err := s.prereq.CheckUnlocked(ctx, userID, StepRef(projectSlug, stepSlug)); err != nil {
+		return model.SubmitResult{}, err
+	}
+
 	result := s.sandboxService.RunProject(ctx, files)
 
 	submission := &model.Submission{
@@ -3,11 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/fs"
 	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/GlebMoskalev/go-path-backend/internal/completion"
 	"github.com/GlebMoskalev/go-path-backend/internal/model"
 	"github.com/GlebMoskalev/go-path-backend/internal/repository"
 	"github.com/google/uuid"
@@ -23,17 +25,34 @@ var (
 type TaskService struct {
 	log            *zap.Logger
 	submissionRepo repository.SubmissionRepository
-	chapters       []model.TaskChapter
-	tasks          map[string]map[string]model.Task
-	tests          map[string]map[string]string
+	prereq         *PrerequisiteService
+	theory         *TheoryService
+	// completionGen заполняет пустые completions.yaml при загрузке; nil = выключено
+	completionGen *completion.Generator
+	chapters      []model.TaskChapter
+	tasks         map[string]map[string]model.Task
+	tests         map[string]map[string]string
+	// translations: locale -> chapter -> task, только переведённые задачи
+	translations        map[string]map[string]map[string]model.Task
+	chapterTranslations map[string]map[string]model.TaskMeta
 }
 
-func NewTaskService(fsys fs.FS, root string, log *zap.Logger, submissionRepo repository.SubmissionRepository) (*TaskService, error) {
+func NewTaskService(
+	fsys fs.FS, root string, log *zap.Logger,
+	submissionRepo repository.SubmissionRepository, prereq *PrerequisiteService, theory *TheoryService,
+	completionGen *completion.Generator,
+) (*TaskService, error) {
 	s := &TaskService{
+		prereq:         prereq,
+		theory:         theory,
+		completionGen:  completionGen,
 		log:            log,
 		tasks:          make(map[string]map[string]model.Task),
 		tests:          make(map[string]map[string]string),
 		submissionRepo: submissionRepo,
+
+		translations:        make(map[string]map[string]map[string]model.Task),
+		chapterTranslations: make(map[string]map[string]model.TaskMeta),
 	}
 
 	if err := s.load(fsys, root); err != nil {
@@ -44,25 +63,28 @@ func NewTaskService(fsys fs.FS, root string, log *zap.Logger, submissionRepo rep
 }
 
 // ListChapters — все главы задач, задачи без description/template
-func (s *TaskService) ListChapters(ctx context.Context, userID *uuid.UUID) []model.TaskChapter {
+func (s *TaskService) ListChapters(ctx context.Context, userID *uuid.UUID, locale string) []model.TaskChapter {
 	result := make([]model.TaskChapter, len(s.chapters))
 	for i, ch := range s.chapters {
-		result[i] = model.TaskChapter{
+		result[i] = s.localizeChapter(model.TaskChapter{
 			Slug:        ch.Slug,
 			Title:       ch.Title,
 			Description: ch.Description,
 			Order:       ch.Order,
 			Tasks:       stripTaskContent(ch.Tasks),
-		}
+			Locale:      ch.Locale,
+		}, locale)
 	}
 
 	if userID != nil {
 		solved := s.getSolvedSet(ctx, *userID)
+		done := s.prereq.lockState(ctx, userID)
 		for i := range result {
 			count := 0
 			for j := range result[i].Tasks {
 				v := solved[result[i].Slug+"/"+result[i].Tasks[j].Slug]
 				result[i].Tasks[j].Solved = &v
+				result[i].Tasks[j].Locked = s.prereq.locked(TaskRef(result[i].Slug, result[i].Tasks[j].Slug), done)
 				if v {
 					count++
 				}
@@ -75,16 +97,17 @@ func (s *TaskService) ListChapters(ctx context.Context, userID *uuid.UUID) []mod
 }
 
 // GetChapter — одна глава, задачи без description/template
-func (s *TaskService) GetChapter(ctx context.Context, slug string, userID *uuid.UUID) (model.TaskChapter, error) {
+func (s *TaskService) GetChapter(ctx context.Context, slug string, userID *uuid.UUID, locale string) (model.TaskChapter, error) {
 	for _, ch := range s.chapters {
 		if ch.Slug == slug {
-			result := model.TaskChapter{
+			result := s.localizeChapter(model.TaskChapter{
 				Slug:        ch.Slug,
 				Title:       ch.Title,
 				Description: ch.Description,
 				Order:       ch.Order,
 				Tasks:       stripTaskContent(ch.Tasks),
-			}
+				Locale:      ch.Locale,
+			}, locale)
 			if userID != nil {
 				solvedCount := s.enrichWithSolved(ctx, *userID, &result)
 				result.SolvedCount = solvedCount
@@ -96,7 +119,7 @@ func (s *TaskService) GetChapter(ctx context.Context, slug string, userID *uuid.
 }
 
 // GetTask — одна задача С description и template (для страницы задачи)
-func (s *TaskService) GetTask(ctx context.Context, chapterSlug, taskSlug string, userID *uuid.UUID) (model.Task, error) {
+func (s *TaskService) GetTask(ctx context.Context, chapterSlug, taskSlug string, userID *uuid.UUID, locale string) (model.Task, error) {
 	chapterTasks, ok := s.tasks[chapterSlug]
 	if !ok {
 		return model.Task{}, ErrTaskChapterNotFound
@@ -105,6 +128,8 @@ func (s *TaskService) GetTask(ctx context.Context, chapterSlug, taskSlug string,
 	if !ok {
 		return model.Task{}, ErrTaskNotFound
 	}
+	task = s.localizeTask(task, locale)
+	task.RelatedLessons = s.theory.localizeLessonLinks(task.RelatedLessons, locale)
 	if userID != nil {
 		submissions, err := s.submissionRepo.ListByUserAndTask(ctx, *userID, chapterSlug, taskSlug)
 		if err != nil {
@@ -119,7 +144,7 @@ func (s *TaskService) GetTask(ctx context.Context, chapterSlug, taskSlug string,
 			}
 		}
 		task.Submissions = submissions
-
+		task.Locked = s.prereq.locked(TaskRef(chapterSlug, taskSlug), s.prereq.lockState(ctx, userID))
 	}
 	return task, nil
 }
@@ -188,10 +213,21 @@ func (s *TaskService) load(fsys fs.FS, root string) error {
 		s.tests[chapter.Slug] = make(map[string]string)
 		for _, t := range tasks {
 			s.tasks[chapter.Slug][t.Slug] = t
+			s.prereq.register(TaskRef(chapter.Slug, t.Slug), t.Requires)
 		}
 		for slug, content := range tests {
 			s.tests[chapter.Slug][slug] = content
 		}
+
+		chapterPath := filepath.Join(root, dir.Name())
+		s.loadChapterTranslations(fsys, chapterPath, chapter.Slug)
+		for _, t := range tasks {
+			s.loadTaskTranslations(fsys, filepath.Join(chapterPath, t.Slug), chapter.Slug, t.Slug)
+		}
+
+		if err := s.linkLessons(chapter.Slug, tasks); err != nil {
+			return err
+		}
 	}
 
 	sort.Slice(s.chapters, func(i, j int) bool {
@@ -224,6 +260,7 @@ func (s *TaskService) loadChapter(fsys fs.FS, root, dirName string) (model.TaskC
 		Title:       meta.Title,
 		Description: meta.Description,
 		Order:       meta.Order,
+		Locale:      model.DefaultLocale,
 	}
 
 	taskDirs, err := fs.ReadDir(fsys, chapterPath)
@@ -291,6 +328,20 @@ func (s *TaskService) loadTask(fsys fs.FS, chapterPath, dirName, chapterSlug str
 		return model.Task{}, "", err
 	}
 	completions := completionsWrapper.Completions
+	if len(completions) == 0 && s.completionGen != nil {
+		generated, err := s.completionGen.Generate(completion.TaskSource(string(templateData)))
+		if err != nil {
+			s.log.Warn("failed to generate completions",
+				zap.String("chapter", chapterSlug), zap.String("task", dirName), zap.Error(err))
+		} else {
+			completions = generated
+		}
+	}
+
+	relatedLessons, err := parseLessonLinks(fm.RelatedLessons)
+	if err != nil {
+		return model.Task{}, "", err
+	}
 
 	task := model.Task{
 		Slug:        dirName,
@@ -302,11 +353,100 @@ func (s *TaskService) loadTask(fsys fs.FS, chapterPath, dirName, chapterSlug str
 		Order:       fm.Order,
 		ChapterSlug: chapterSlug,
 		Completions: completions,
+		Locale:      model.DefaultLocale,
+		Requires:    fm.Requires,
+
+		RelatedLessons: relatedLessons,
 	}
 
 	return task, string(testData), nil
 }
 
+// loadChapterTranslations читает meta.<locale>.yaml главы, если они есть
+func (s *TaskService) loadChapterTranslations(fsys fs.FS, chapterPath, chapterSlug string) {
+	for _, locale := range translationLocales() {
+		metaData, err := fs.ReadFile(fsys, filepath.Join(chapterPath, localizedName("meta", ".yaml", locale)))
+		if err != nil {
+			continue
+		}
+
+		var meta model.TaskMeta
+		if err := yaml.Unmarshal(metaData, &meta); err != nil {
+			s.log.Warn("skipping task chapter translation",
+				zap.String("chapter", chapterSlug), zap.String("locale", locale), zap.Error(err))
+			continue
+		}
+
+		if s.chapterTranslations[locale] == nil {
+			s.chapterTranslations[locale] = make(map[string]model.TaskMeta)
+		}
+		s.chapterTranslations[locale][chapterSlug] = meta
+	}
+}
+
+// loadTaskTranslations читает task.<locale>.md задачи, если они есть
+func (s *TaskService) loadTaskTranslations(fsys fs.FS, taskPath, chapterSlug, taskSlug string) {
+	for _, locale := range translationLocales() {
+		taskData, err := fs.ReadFile(fsys, filepath.Join(taskPath, localizedName("task", ".md", locale)))
+		if err != nil {
+			continue
+		}
+
+		fm, description, err := parseTaskFrontmatter(string(taskData))
+		if err != nil {
+			s.log.Warn("skipping task translation",
+				zap.String("chapter", chapterSlug), zap.String("task", taskSlug), zap.String("locale", locale), zap.Error(err))
+			continue
+		}
+
+		if s.translations[locale] == nil {
+			s.translations[locale] = make(map[string]map[string]model.Task)
+		}
+		if s.translations[locale][chapterSlug] == nil {
+			s.translations[locale][chapterSlug] = make(map[string]model.Task)
+		}
+		s.translations[locale][chapterSlug][taskSlug] = model.Task{
+			Slug:        taskSlug,
+			Title:       fm.Title,
+			Description: description,
+			Hints:       fm.Hints,
+			ChapterSlug: chapterSlug,
+			Locale:      locale,
+		}
+	}
+}
+
+// linkLessons проверяет related_lessons задач главы и регистрирует задачи в уроках теории
+func (s *TaskService) linkLessons(chapterSlug string, tasks []model.Task) error {
+	for _, t := range tasks {
+		if len(t.RelatedLessons) == 0 {
+			continue
+		}
+
+		titles := make(map[string]string)
+		for locale, chapters := range s.translations {
+			if tr, ok := chapters[chapterSlug][t.Slug]; ok {
+				titles[locale] = tr.Title
+			}
+		}
+
+		links, err := s.theory.linkPractice(t.RelatedLessons, model.PracticeTask{
+			Type:        model.PracticeTypeTask,
+			ChapterSlug: chapterSlug,
+			Slug:        t.Slug,
+			Title:       t.Title,
+			Difficulty:  t.Difficulty,
+		}, TaskRef(chapterSlug, t.Slug), titles)
+		if err != nil {
+			return fmt.Errorf("task %s/%s related_lessons: %w", chapterSlug, t.Slug, err)
+		}
+
+		t.RelatedLessons = links
+		s.tasks[chapterSlug][t.Slug] = t
+	}
+	return nil
+}
+
 func parseTaskFrontmatter(raw string) (model.TaskFrontmatter, string, error) {
 	const delimiter = "---"
 
@@ -341,11 +481,47 @@ func stripTaskContent(tasks []model.Task) []model.Task {
 			Difficulty:  t.Difficulty,
 			Order:       t.Order,
 			ChapterSlug: t.ChapterSlug,
+			Locale:      t.Locale,
+			Requires:    t.Requires,
 		}
 	}
 	return stripped
 }
 
+// localizeChapter подменяет заголовки главы и её задач переводом, если он есть
+func (s *TaskService) localizeChapter(ch model.TaskChapter, locale string) model.TaskChapter {
+	if meta, ok := s.chapterTranslations[locale][ch.Slug]; ok {
+		ch.Title = meta.Title
+		ch.Description = meta.Description
+		ch.Locale = locale
+	}
+	for i := range ch.Tasks {
+		ch.Tasks[i] = s.localizeTask(ch.Tasks[i], locale)
+	}
+	return ch
+}
+
+// localizeTask подменяет задачу переводом; без перевода задача остаётся на языке по умолчанию
+func (s *TaskService) localizeTask(t model.Task, locale string) model.Task {
+	if meta, ok := s.chapterTranslations[locale][t.ChapterSlug]; ok && t.ChapterTitle != "" {
+		t.ChapterTitle = meta.Title
+	}
+
+	tr, ok := s.translations[locale][t.ChapterSlug][t.Slug]
+	if !ok {
+		return t
+	}
+	t.Title = tr.Title
+	if t.Description != "" {
+		t.Description = tr.Description
+	}
+	if t.Hints != nil {
+		t.Hints = tr.Hints
+	}
+	t.Locale = locale
+	return t
+}
+
 func (s *TaskService) totalTasks() int {
 	count := 0
 	for _, ch := range s.chapters {
@@ -368,9 +544,11 @@ func (s *TaskService) getSolvedSet(ctx context.Context, userID uuid.UUID) map[st
 
 func (s *TaskService) enrichWithSolved(ctx context.Context, userID uuid.UUID, chapter *model.TaskChapter) int {
 	solvedSet := s.getSolvedSet(ctx, userID)
+	done := s.prereq.lockState(ctx, &userID)
 	for i := range chapter.Tasks {
 		v := solvedSet[chapter.Slug+"/"+chapter.Tasks[i].Slug]
 		chapter.Tasks[i].Solved = &v
+		chapter.Tasks[i].Locked = s.prereq.locked(TaskRef(chapter.Slug, chapter.Tasks[i].Slug), done)
 	}
 	return len(solvedSet)
 }
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/fs"
 	"path/filepath"
 	"sort"
@@ -18,6 +19,8 @@ import (
 var (
 	ErrChapterNotFound = errors.New("chapter not found")
 	ErrLessonNotFound  = errors.New("lesson not found")
+	// ErrLessonQuizNotPassed — включён QUIZ_REQUIRE_LESSON_QUIZ, а мини-квиз урока не пройден
+	ErrLessonQuizNotPassed = errors.New("lesson quiz not passed")
 )
 
 type TheoryService struct {
@@ -25,13 +28,37 @@ type TheoryService struct {
 	chapters     []model.Chapter
 	lessons      map[string]map[string]model.Lesson
 	progressRepo repository.TheoryProgressRepository
+	prereq       *PrerequisiteService
+	// translations: locale -> chapter -> lesson, только переведённые уроки
+	translations        map[string]map[string]map[string]model.Lesson
+	chapterTranslations map[string]map[string]model.ChapterMeta
+	// practice: chapter -> lesson -> задачи, ссылающиеся на урок через related_lessons
+	practice map[string]map[string][]practiceItem
+	// lessonQuiz задаётся через SetLessonQuiz; nil — мини-квиз не обязателен
+	lessonQuiz *QuizService
 }
 
-func NewTheoryService(fsys fs.FS, root string, log *zap.Logger, progressRepo repository.TheoryProgressRepository) (*TheoryService, error) {
+type practiceItem struct {
+	task model.PracticeTask
+	// ref — TaskRef или StepRef для проверки решения
+	ref string
+	// titles: locale -> переведённый заголовок
+	titles map[string]string
+}
+
+func NewTheoryService(
+	fsys fs.FS, root string, log *zap.Logger,
+	progressRepo repository.TheoryProgressRepository, prereq *PrerequisiteService,
+) (*TheoryService, error) {
 	s := TheoryService{
 		log:          log,
 		lessons:      make(map[string]map[string]model.Lesson),
 		progressRepo: progressRepo,
+		prereq:       prereq,
+
+		translations:        make(map[string]map[string]map[string]model.Lesson),
+		chapterTranslations: make(map[string]map[string]model.ChapterMeta),
+		practice:            make(map[string]map[string][]practiceItem),
 	}
 
 	if err := s.load(fsys, root); err != nil {
@@ -41,8 +68,14 @@ func NewTheoryService(fsys fs.FS, root string, log *zap.Logger, progressRepo rep
 	return &s, nil
 }
 
+// SetLessonQuiz требует прохождения мини-квиза урока перед его завершением.
+// TheoryService создаётся раньше QuizService, поэтому связь задаётся отдельно
+func (s *TheoryService) SetLessonQuiz(quiz *QuizService) {
+	s.lessonQuiz = quiz
+}
+
 // ListChapters возвращает все главы со списком уроков, но БЕЗ содержимого уроков
-func (s *TheoryService) ListChapters(ctx context.Context, userID *uuid.UUID) []model.Chapter {
+func (s *TheoryService) ListChapters(ctx context.Context, userID *uuid.UUID, locale string) []model.Chapter {
 	result := make([]model.Chapter, len(s.chapters))
 
 	var completed map[string]map[string]bool
@@ -53,15 +86,17 @@ func (s *TheoryService) ListChapters(ctx context.Context, userID *uuid.UUID) []m
 			s.log.Error("failed to get completed lessons", zap.Error(err))
 		}
 	}
+	done := s.prereq.lockState(ctx, userID)
 
 	for i, ch := range s.chapters {
-		result[i] = model.Chapter{
+		result[i] = s.localizeChapter(model.Chapter{
 			Slug:        ch.Slug,
 			Title:       ch.Title,
 			Description: ch.Description,
 			Order:       ch.Order,
 			Lessons:     stripContent(ch.Lessons),
-		}
+			Locale:      ch.Locale,
+		}, locale)
 
 		if userID != nil {
 			total := len(ch.Lessons)
@@ -78,6 +113,7 @@ func (s *TheoryService) ListChapters(ctx context.Context, userID *uuid.UUID) []m
 			for j := range result[i].Lessons {
 				isCompleted := completed[ch.Slug] != nil && completed[ch.Slug][result[i].Lessons[j].Slug]
 				result[i].Lessons[j].Completed = &isCompleted
+				result[i].Lessons[j].Locked = s.prereq.locked(LessonRef(ch.Slug, result[i].Lessons[j].Slug), done)
 			}
 		}
 	}
@@ -88,19 +124,20 @@ func (s *TheoryService) ListChapters(ctx context.Context, userID *uuid.UUID) []m
 // GetChapter — возвращает одну главу по её slug (например "01-basics").
 // Уроки включены, но без содержимого markdown.
 // Если глава не найдена — возвращает ErrChapterNotFound.
-func (s *TheoryService) GetChapter(ctx context.Context, slug string, userID *uuid.UUID) (model.Chapter, error) {
+func (s *TheoryService) GetChapter(ctx context.Context, slug string, userID *uuid.UUID, locale string) (model.Chapter, error) {
 	var chapter model.Chapter
 	var found bool
 
 	for _, ch := range s.chapters {
 		if ch.Slug == slug {
-			chapter = model.Chapter{
+			chapter = s.localizeChapter(model.Chapter{
 				Slug:        ch.Slug,
 				Title:       ch.Title,
 				Description: ch.Description,
 				Order:       ch.Order,
 				Lessons:     stripContent(ch.Lessons),
-			}
+				Locale:      ch.Locale,
+			}, locale)
 			found = true
 			break
 		}
@@ -125,9 +162,11 @@ func (s *TheoryService) GetChapter(ctx context.Context, slug string, userID *uui
 				Completed: completedCount,
 			}
 
+			done := s.prereq.lockState(ctx, userID)
 			for i := range chapter.Lessons {
 				isCompleted := completed[slug] != nil && completed[slug][chapter.Lessons[i].Slug]
 				chapter.Lessons[i].Completed = &isCompleted
+				chapter.Lessons[i].Locked = s.prereq.locked(LessonRef(slug, chapter.Lessons[i].Slug), done)
 			}
 		}
 	}
@@ -137,7 +176,8 @@ func (s *TheoryService) GetChapter(ctx context.Context, slug string, userID *uui
 
 // GetLesson — возвращает один урок С содержимым markdown.
 // chapterSlug — slug главы, lessonSlug — slug урока.
-func (s *TheoryService) GetLesson(ctx context.Context, chapterSlug, lessonSlug string, userID *uuid.UUID) (model.Lesson, error) {
+// Если перевода на locale нет, возвращается урок на языке по умолчанию.
+func (s *TheoryService) GetLesson(ctx context.Context, chapterSlug, lessonSlug string, userID *uuid.UUID, locale string) (model.Lesson, error) {
 	chapterLessons, ok := s.lessons[chapterSlug]
 	if !ok {
 		return model.Lesson{}, ErrChapterNotFound
@@ -147,7 +187,9 @@ func (s *TheoryService) GetLesson(ctx context.Context, chapterSlug, lessonSlug s
 	if !ok {
 		return model.Lesson{}, ErrLessonNotFound
 	}
+	lesson = s.localizeLesson(lesson, locale)
 
+	done := s.prereq.lockState(ctx, userID)
 	if userID != nil {
 		isCompleted, err := s.progressRepo.IsCompleted(ctx, *userID, chapterSlug, lessonSlug)
 		if err != nil {
@@ -155,11 +197,36 @@ func (s *TheoryService) GetLesson(ctx context.Context, chapterSlug, lessonSlug s
 		} else {
 			lesson.Completed = &isCompleted
 		}
+		lesson.Locked = s.prereq.locked(LessonRef(chapterSlug, lessonSlug), done)
 	}
+	lesson.PracticeTasks = s.practiceTasks(chapterSlug, lessonSlug, locale, done)
 
 	return lesson, nil
 }
 
+// GetSnippet ищет пример в уроке на locale, затем в уроке на языке по умолчанию
+func (s *TheoryService) GetSnippet(chapterSlug, lessonSlug, snippetID, locale string) (model.Snippet, error) {
+	chapterLessons, ok := s.lessons[chapterSlug]
+	if !ok {
+		return model.Snippet{}, ErrChapterNotFound
+	}
+	lesson, ok := chapterLessons[lessonSlug]
+	if !ok {
+		return model.Snippet{}, ErrLessonNotFound
+	}
+
+	candidates := lesson.Snippets
+	if tr, ok := s.translations[locale][chapterSlug][lessonSlug]; ok {
+		candidates = append(tr.Snippets[:len(tr.Snippets):len(tr.Snippets)], candidates...)
+	}
+	for _, snippet := range candidates {
+		if snippet.ID == snippetID {
+			return snippet, nil
+		}
+	}
+	return model.Snippet{}, ErrSnippetNotFound
+}
+
 // MarkLessonCompleted отмечает урок как прочитанный
 func (s *TheoryService) MarkLessonCompleted(ctx context.Context, userID uuid.UUID, chapterSlug, lessonSlug string) error {
 	chapterLessons, ok := s.lessons[chapterSlug]
@@ -171,6 +238,20 @@ func (s *TheoryService) MarkLessonCompleted(ctx context.Context, userID uuid.UUI
 		return ErrLessonNotFound
 	}
 
+	if err := s.prereq.CheckUnlocked(ctx, userID, LessonRef(chapterSlug, lessonSlug)); err != nil {
+		return err
+	}
+
+	if s.lessonQuiz != nil {
+		passed, err := s.lessonQuiz.LessonQuizPassed(ctx, userID, chapterSlug, lessonSlug)
+		if err != nil {
+			return err
+		}
+		if !passed {
+			return ErrLessonQuizNotPassed
+		}
+	}
+
 	return s.progressRepo.MarkCompleted(ctx, userID, chapterSlug, lessonSlug)
 }
 
@@ -210,7 +291,7 @@ func (s *TheoryService) load(fsys fs.FS, root string) error {
 			continue
 		}
 
-		chapter, lessons, err := s.loadChapter(fsys, root, dir.Name())
+		chapter, lessons, translated, err := s.loadChapter(fsys, root, dir.Name())
 		if err != nil {
 			s.log.Warn("skipping chapter", zap.String("dir", dir.Name()), zap.Error(err))
 			continue
@@ -221,7 +302,24 @@ func (s *TheoryService) load(fsys fs.FS, root string) error {
 		s.lessons[chapter.Slug] = make(map[string]model.Lesson)
 		for _, l := range lessons {
 			s.lessons[chapter.Slug][l.Slug] = l
+			s.prereq.register(LessonRef(chapter.Slug, l.Slug), l.Requires)
 		}
+
+		for _, l := range translated {
+			if _, ok := s.lessons[chapter.Slug][l.Slug]; !ok {
+				s.log.Warn("skipping lesson translation without original",
+					zap.String("chapter", chapter.Slug), zap.String("lesson", l.Slug), zap.String("locale", l.Locale))
+				continue
+			}
+			if s.translations[l.Locale] == nil {
+				s.translations[l.Locale] = make(map[string]map[string]model.Lesson)
+			}
+			if s.translations[l.Locale][chapter.Slug] == nil {
+				s.translations[l.Locale][chapter.Slug] = make(map[string]model.Lesson)
+			}
+			s.translations[l.Locale][chapter.Slug][l.Slug] = l
+		}
+		s.loadChapterTranslations(fsys, filepath.Join(root, dir.Name()), chapter.Slug)
 	}
 
 	sort.Slice(s.chapters, func(i, j int) bool {
@@ -233,18 +331,18 @@ func (s *TheoryService) load(fsys fs.FS, root string) error {
 	return nil
 }
 
-func (s *TheoryService) loadChapter(fsys fs.FS, root, dirName string) (model.Chapter, []model.Lesson, error) {
+func (s *TheoryService) loadChapter(fsys fs.FS, root, dirName string) (model.Chapter, []model.Lesson, []model.Lesson, error) {
 	chapterPath := filepath.Join(root, dirName)
 	metaPath := filepath.Join(chapterPath, "meta.yaml")
 
 	metaData, err := fs.ReadFile(fsys, metaPath)
 	if err != nil {
-		return model.Chapter{}, nil, err
+		return model.Chapter{}, nil, nil, err
 	}
 
 	var meta model.ChapterMeta
 	if err := yaml.Unmarshal(metaData, &meta); err != nil {
-		return model.Chapter{}, nil, err
+		return model.Chapter{}, nil, nil, err
 	}
 
 	chapter := model.Chapter{
@@ -252,14 +350,15 @@ func (s *TheoryService) loadChapter(fsys fs.FS, root, dirName string) (model.Cha
 		Title:       meta.Title,
 		Description: meta.Description,
 		Order:       meta.Order,
+		Locale:      model.DefaultLocale,
 	}
 
 	files, err := fs.ReadDir(fsys, chapterPath)
 	if err != nil {
-		return model.Chapter{}, nil, err
+		return model.Chapter{}, nil, nil, err
 	}
 
-	var lessons []model.Lesson
+	var lessons, translated []model.Lesson
 	for _, f := range files {
 		if f.IsDir() || !strings.HasSuffix(f.Name(), ".md") {
 			continue
@@ -270,6 +369,10 @@ func (s *TheoryService) loadChapter(fsys fs.FS, root, dirName string) (model.Cha
 			s.log.Warn("skipping lesson", zap.String("file", f.Name()), zap.Error(err))
 			continue
 		}
+		if lesson.Locale != model.DefaultLocale {
+			translated = append(translated, lesson)
+			continue
+		}
 		lesson.ChapterTitle = chapter.Title
 		lessons = append(lessons, lesson)
 	}
@@ -278,7 +381,29 @@ func (s *TheoryService) loadChapter(fsys fs.FS, root, dirName string) (model.Cha
 		return lessons[i].Order < lessons[j].Order
 	})
 
-	return chapter, lessons, nil
+	return chapter, lessons, translated, nil
+}
+
+// loadChapterTranslations читает meta.<locale>.yaml главы, если они есть
+func (s *TheoryService) loadChapterTranslations(fsys fs.FS, chapterPath, chapterSlug string) {
+	for _, locale := range translationLocales() {
+		metaData, err := fs.ReadFile(fsys, filepath.Join(chapterPath, localizedName("meta", ".yaml", locale)))
+		if err != nil {
+			continue
+		}
+
+		var meta model.ChapterMeta
+		if err := yaml.Unmarshal(metaData, &meta); err != nil {
+			s.log.Warn("skipping chapter translation",
+				zap.String("chapter", chapterSlug), zap.String("locale", locale), zap.Error(err))
+			continue
+		}
+
+		if s.chapterTranslations[locale] == nil {
+			s.chapterTranslations[locale] = make(map[string]model.ChapterMeta)
+		}
+		s.chapterTranslations[locale][chapterSlug] = meta
+	}
 }
 
 func (s *TheoryService) loadLesson(fsys fs.FS, chapterPath, fileName, chapterSlug string) (model.Lesson, error) {
@@ -293,7 +418,7 @@ func (s *TheoryService) loadLesson(fsys fs.FS, chapterPath, fileName, chapterSlu
 		return model.Lesson{}, err
 	}
 
-	slug := strings.TrimSuffix(fileName, ".md")
+	slug, locale := splitLocaleSuffix(fileName, ".md")
 
 	return model.Lesson{
 		Slug:        slug,
@@ -302,6 +427,9 @@ func (s *TheoryService) loadLesson(fsys fs.FS, chapterPath, fileName, chapterSlu
 		Order:       fm.Order,
 		ChapterSlug: chapterSlug,
 		Content:     content,
+		Locale:      locale,
+		Requires:    fm.Requires,
+		Snippets:    extractSnippets(content),
 	}, nil
 }
 
@@ -340,6 +468,89 @@ func (s *TheoryService) totalLessons() int {
 	return count
 }
 
+// parseLessonLinks разбирает related_lessons из frontmatter; заголовки заполняет linkPractice
+func parseLessonLinks(refs []string) ([]model.LessonLink, error) {
+	links := make([]model.LessonLink, 0, len(refs))
+	for _, ref := range refs {
+		chapterSlug, lessonSlug, ok := strings.Cut(strings.TrimPrefix(ref, lessonRefPrefix), "/")
+		if !ok || chapterSlug == "" || lessonSlug == "" {
+			return nil, fmt.Errorf("invalid related lesson %q, expected chapter/lesson", ref)
+		}
+		links = append(links, model.LessonLink{ChapterSlug: chapterSlug, LessonSlug: lessonSlug})
+	}
+	return links, nil
+}
+
+// linkPractice проверяет, что связанные уроки существуют, и добавляет задачу в их обратный индекс.
+// Возвращает ссылки с заголовками уроков.
+func (s *TheoryService) linkPractice(links []model.LessonLink, task model.PracticeTask, ref string, titles map[string]string) ([]model.LessonLink, error) {
+	resolved := make([]model.LessonLink, 0, len(links))
+	for _, link := range links {
+		lesson, ok := s.lessons[link.ChapterSlug][link.LessonSlug]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s/%s", ErrLessonNotFound, link.ChapterSlug, link.LessonSlug)
+		}
+		link.Title = lesson.Title
+		resolved = append(resolved, link)
+
+		if s.practice[link.ChapterSlug] == nil {
+			s.practice[link.ChapterSlug] = make(map[string][]practiceItem)
+		}
+		s.practice[link.ChapterSlug][link.LessonSlug] = append(s.practice[link.ChapterSlug][link.LessonSlug],
+			practiceItem{task: task, ref: ref, titles: titles})
+	}
+	return resolved, nil
+}
+
+// lessonExists проверяет главу и урок, возвращая те же ошибки, что GetLesson
+func (s *TheoryService) lessonExists(chapterSlug, lessonSlug string) error {
+	chapterLessons, ok := s.lessons[chapterSlug]
+	if !ok {
+		return ErrChapterNotFound
+	}
+	if _, ok := chapterLessons[lessonSlug]; !ok {
+		return ErrLessonNotFound
+	}
+	return nil
+}
+
+// practiceTasks возвращает задачи урока; done == nil для гостя, тогда Solved не заполняется
+func (s *TheoryService) practiceTasks(chapterSlug, lessonSlug, locale string, done map[string]bool) []model.PracticeTask {
+	items := s.practice[chapterSlug][lessonSlug]
+	if len(items) == 0 {
+		return nil
+	}
+
+	tasks := make([]model.PracticeTask, len(items))
+	for i, item := range items {
+		tasks[i] = item.task
+		if title, ok := item.titles[locale]; ok {
+			tasks[i].Title = title
+		}
+		if done != nil {
+			solved := done[item.ref]
+			tasks[i].Solved = &solved
+		}
+	}
+	return tasks
+}
+
+// localizeLessonLinks подменяет заголовки связанных уроков переводом, если он есть
+func (s *TheoryService) localizeLessonLinks(links []model.LessonLink, locale string) []model.LessonLink {
+	if len(links) == 0 {
+		return links
+	}
+
+	result := make([]model.LessonLink, len(links))
+	for i, link := range links {
+		if tr, ok := s.translations[locale][link.ChapterSlug][link.LessonSlug]; ok {
+			link.Title = tr.Title
+		}
+		result[i] = link
+	}
+	return result
+}
+
 func stripContent(lessons []model.Lesson) []model.Lesson {
 	stripped := make([]model.Lesson, len(lessons))
 	for i, l := range lessons {
@@ -349,8 +560,43 @@ func stripContent(lessons []model.Lesson) []model.Lesson {
 			Description: l.Description,
 			Order:       l.Order,
 			ChapterSlug: l.ChapterSlug,
+			Locale:      l.Locale,
+			Requires:    l.Requires,
 		}
 	}
 
 	return stripped
 }
+
+// localizeChapter подменяет заголовки главы и её уроков переводом, если он есть
+func (s *TheoryService) localizeChapter(ch model.Chapter, locale string) model.Chapter {
+	if meta, ok := s.chapterTranslations[locale][ch.Slug]; ok {
+		ch.Title = meta.Title
+		ch.Description = meta.Description
+		ch.Locale = locale
+	}
+	for i := range ch.Lessons {
+		ch.Lessons[i] = s.localizeLesson(ch.Lessons[i], locale)
+	}
+	return ch
+}
+
+// localizeLesson подменяет урок переводом; без перевода урок остаётся на языке по умолчанию
+func (s *TheoryService) localizeLesson(l model.Lesson, locale string) model.Lesson {
+	if meta, ok := s.chapterTranslations[locale][l.ChapterSlug]; ok && l.ChapterTitle != "" {
+		l.ChapterTitle = meta.Title
+	}
+
+	tr, ok := s.translations[locale][l.ChapterSlug][l.Slug]
+	if !ok {
+		return l
+	}
+	l.Title = tr.Title
+	l.Description = tr.Description
+	if l.Content != "" {
+		l.Content = tr.Content
+		l.Snippets = tr.Snippets
+	}
+	l.Locale = locale
+	return l
+}
@@ -3,24 +3,45 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/GlebMoskalev/go-path-backend/internal/config"
 	"github.com/GlebMoskalev/go-path-backend/internal/model"
 	"github.com/GlebMoskalev/go-path-backend/internal/repository"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+const (
+	// purgeBatchSize — сколько аккаунтов удаляется за одну транзакцию фоновой чистки
+	purgeBatchSize = 100
+	// handleChangeInterval — как часто можно менять handle, чтобы адреса не перехватывали
+	handleChangeInterval = 7 * 24 * time.Hour
+)
+
 type UserService struct {
-	log      *zap.Logger
-	userRepo repository.UserRepository
+	log           *zap.Logger
+	userRepo      repository.UserRepository
+	exportRepo    repository.ExportRepository
+	deletionGrace time.Duration
 }
 
 var (
 	ErrUserNotFound = errors.New("user not found")
 )
 
-func NewUserService(log *zap.Logger, userRepo repository.UserRepository) *UserService {
-	return &UserService{log: log, userRepo: userRepo}
+func NewUserService(
+	log *zap.Logger,
+	userRepo repository.UserRepository,
+	exportRepo repository.ExportRepository,
+	accountCfg config.AccountConfig,
+) *UserService {
+	return &UserService{
+		log:           log,
+		userRepo:      userRepo,
+		exportRepo:    exportRepo,
+		deletionGrace: accountCfg.DeletionGrace,
+	}
 }
 
 func (s *UserService) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
@@ -37,6 +58,19 @@ func (s *UserService) GetByID(ctx context.Context, id uuid.UUID) (*model.User, e
 	return user, nil
 }
 
+// GetAuthState — token version, роль и статус для проверки токена; читается через кеш
+func (s *UserService) GetAuthState(ctx context.Context, id uuid.UUID) (*model.UserAuthState, error) {
+	state, err := s.userRepo.GetAuthState(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.UserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		s.log.Error("failed to get user auth state", zap.Error(err))
+		return nil, err
+	}
+	return state, nil
+}
+
 func (s *UserService) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
@@ -48,27 +82,110 @@ func (s *UserService) GetByEmail(ctx context.Context, email string) (*model.User
 	return user, nil
 }
 
-func (s *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, name, picture string) error {
+// UpdateProfile проверяет новый handle и сохраняет его вместе с остальным профилем:
+// при отказе в handle профиль не меняется
+func (s *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, update model.ProfileUpdate) error {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		s.log.Error("failed to get user by ID", zap.Error(err))
 		return err
 	}
 
-	user.Name = name
-	user.Picture = picture
+	if update.Handle != nil {
+		handle := normalizeHandle(*update.Handle)
+		if handle != "" && handle != user.Handle {
+			if err := validateHandle(handle); err != nil {
+				return err
+			}
+		}
+		user.Handle = handle
+	}
 
-	err = s.userRepo.Update(ctx, user)
-	if err != nil {
+	user.Name = update.Name
+	user.Picture = update.Picture
+	if update.Privacy != nil {
+		user.Privacy = *update.Privacy
+	}
+	if update.Locale != nil {
+		if *update.Locale != "" && !model.IsSupportedLocale(*update.Locale) {
+			return ErrUnsupportedLocale
+		}
+		user.Locale = *update.Locale
+	}
+
+	err = s.userRepo.Update(ctx, user, time.Now().Add(-handleChangeInterval))
+	switch {
+	case errors.Is(err, repository.UserHandleTaken):
+		return ErrHandleTaken
+	case errors.Is(err, repository.UserHandleLimited):
+		return ErrHandleLimited
+	case err != nil:
 		s.log.Error("failed to update user", zap.Error(err))
 	}
 	return err
 }
 
-func (s *UserService) DeleteAccount(ctx context.Context, userID uuid.UUID) error {
-	err := s.userRepo.Delete(ctx, userID)
+// DeleteAccount блокирует аккаунт и назначает удаление через deletionGrace;
+// до этого срока вход предлагает восстановление, после — данные стирает PurgeDeleted
+func (s *UserService) DeleteAccount(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	user, err := s.userRepo.ScheduleDeletion(ctx, userID, time.Now().Add(s.deletionGrace))
 	if err != nil {
-		s.log.Error("failed to delete user", zap.Error(err))
+		if errors.Is(err, repository.UserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		s.log.Error("failed to schedule user deletion", zap.Error(err))
+		return nil, err
+	}
+
+	s.deleteExport(ctx, userID)
+
+	s.log.Info("account scheduled for deletion",
+		zap.String("user_id", userID.String()), zap.Timep("delete_after", user.DeleteAfter))
+	return user, nil
+}
+
+// PurgeDeleted стирает аккаунты с истёкшим сроком восстановления пачками по purgeBatchSize
+func (s *UserService) PurgeDeleted(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		purged, err := s.userRepo.PurgeDeleted(ctx, time.Now(), purgeBatchSize)
+		if err != nil {
+			s.log.Error("failed to purge deleted accounts", zap.Error(err))
+			return total, err
+		}
+		for _, id := range purged {
+			s.deleteExport(ctx, id)
+			s.log.Info("account purged", zap.String("user_id", id.String()))
+		}
+		total += len(purged)
+		if len(purged) < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// deleteExport убирает выгрузку данных удаляемого аккаунта; архив и так истечёт,
+// поэтому ошибка только логируется
+func (s *UserService) deleteExport(ctx context.Context, userID uuid.UUID) {
+	if err := s.exportRepo.Delete(ctx, userID); err != nil {
+		s.log.Error("failed to delete user export", zap.String("user_id", userID.String()), zap.Error(err))
+	}
+}
+
+// RunPurge запускает PurgeDeleted каждые interval, пока не отменён ctx; interval <= 0 выключает чистку
+func (s *UserService) RunPurge(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.PurgeDeleted(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
-	return err
 }
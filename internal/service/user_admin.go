@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	userListDefaultLimit = 50
+	userListMaxLimit     = 200
+)
+
+var (
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrSelfModification — админ не может снять с себя роль или заблокировать себя
+	ErrSelfModification = errors.New("cannot change own role or status")
+)
+
+func (s *UserService) ListUsers(ctx context.Context, filter model.UserFilter) (*model.UserList, error) {
+	if filter.Role != "" && !filter.Role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = userListDefaultLimit
+	}
+	filter.Limit = min(filter.Limit, userListMaxLimit)
+	filter.Offset = max(filter.Offset, 0)
+
+	users, total, err := s.userRepo.List(ctx, filter)
+	if err != nil {
+		s.log.Error("failed to list users", zap.Error(err))
+		return nil, err
+	}
+	return &model.UserList{Users: users, Total: total}, nil
+}
+
+// ChangeRole меняет роль; наш middleware читает роль из базы, поэтому она действует сразу,
+// а в claims новая роль попадёт при следующем обновлении токена
+func (s *UserService) ChangeRole(ctx context.Context, actorID, userID uuid.UUID, role model.Role) (*model.User, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	if actorID == userID {
+		return nil, ErrSelfModification
+	}
+
+	user, err := s.userRepo.SetRole(ctx, userID, role)
+	if err != nil {
+		if errors.Is(err, repository.UserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		s.log.Error("failed to set user role", zap.Error(err))
+		return nil, err
+	}
+
+	s.log.Info("user role changed",
+		zap.String("actor_id", actorID.String()), zap.String("user_id", userID.String()), zap.String("role", string(role)))
+	return user, nil
+}
+
+// SetActive блокирует или разблокирует аккаунт; заблокированный пользователь выходит на всех устройствах
+func (s *UserService) SetActive(ctx context.Context, actorID, userID uuid.UUID, active bool) (*model.User, error) {
+	if actorID == userID {
+		return nil, ErrSelfModification
+	}
+
+	user, err := s.userRepo.SetActive(ctx, userID, active)
+	if err != nil {
+		if errors.Is(err, repository.UserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		s.log.Error("failed to set user active", zap.Error(err))
+		return nil, err
+	}
+
+	s.log.Info("user active status changed",
+		zap.String("actor_id", actorID.String()), zap.String("user_id", userID.String()), zap.Bool("active", active))
+	return user, nil
+}
+
+// BootstrapAdmins выдаёт роль admin уже зарегистрированным пользователям из ADMIN_EMAILS;
+// новые получают её при первом входе
+func (s *UserService) BootstrapAdmins(ctx context.Context, emails []string) error {
+	if len(emails) == 0 {
+		return nil
+	}
+
+	promoted, err := s.userRepo.PromoteAdmins(ctx, emails)
+	if err != nil {
+		s.log.Error("failed to promote admins", zap.Error(err))
+		return err
+	}
+	if len(promoted) > 0 {
+		s.log.Info("promoted users to admin from config", zap.Int("count", len(promoted)))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/GlebMoskalev/go-path-backend/internal/model"
+	"github.com/GlebMoskalev/go-path-backend/internal/repository"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidHandle  = errors.New("handle must be 3-30 characters: lowercase latin letters, digits, '-' or '_', starting with a letter")
+	ErrHandleReserved = errors.New("handle is reserved")
+	ErrHandleTaken    = errors.New("handle is already taken")
+	// ErrHandleLimited — handle можно менять не чаще раза в handleChangeInterval
+	ErrHandleLimited     = errors.New("handle can be changed once every 7 days")
+	ErrProfileNotFound   = errors.New("profile not found")
+	ErrUnsupportedLocale = errors.New("unsupported locale")
+)
+
+var handlePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{2,29}$`)
+
+// reservedHandles — адреса, которые можно спутать с разделами сайта или с администрацией
+var reservedHandles = map[string]bool{
+	"about": true, "account": true, "admin": true, "administrator": true, "api": true,
+	"auth": true, "blog": true, "dashboard": true, "docs": true, "gopath": true,
+	"go-path": true, "help": true, "login": true, "logout": true, "mentor": true,
+	"moderator": true, "null": true, "official": true, "privacy": true, "profile": true,
+	"projects": true, "quiz": true, "root": true, "search": true, "security": true,
+	"settings": true, "signin": true, "signup": true, "staff": true, "static": true,
+	"stats": true, "support": true, "system": true, "tasks": true, "terms": true,
+	"theory": true, "undefined": true, "user": true, "users": true, "www": true,
+}
+
+// normalizeHandle приводит handle к виду, в котором он хранится: без пробелов и в нижнем регистре
+func normalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimSpace(handle))
+}
+
+func validateHandle(handle string) error {
+	if !handlePattern.MatchString(handle) {
+		return ErrInvalidHandle
+	}
+	if reservedHandles[handle] {
+		return ErrHandleReserved
+	}
+	return nil
+}
+
+// GetPublicUser находит пользователя по handle; закрытый или неактивный профиль не отличается от несуществующего
+func (s *UserService) GetPublicUser(ctx context.Context, handle string) (*model.User, error) {
+	handle = normalizeHandle(handle)
+	if !handlePattern.MatchString(handle) {
+		return nil, ErrProfileNotFound
+	}
+
+	user, err := s.userRepo.GetByHandle(ctx, handle)
+	if err != nil {
+		if errors.Is(err, repository.UserNotFound) {
+			return nil, ErrProfileNotFound
+		}
+		s.log.Error("failed to get user by handle", zap.Error(err))
+		return nil, err
+	}
+	if !user.IsActive || !user.Privacy.Public {
+		return nil, ErrProfileNotFound
+	}
+	return user, nil
+}
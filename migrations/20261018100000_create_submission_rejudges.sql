@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE submission_rejudges(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    submission_id UUID NOT NULL REFERENCES submissions(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    old_passed BOOLEAN NOT NULL,
+    new_passed BOOLEAN NOT NULL,
+    old_result JSONB,
+    new_result JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_submission_rejudges_submission ON submission_rejudges(submission_id);
+CREATE INDEX idx_submissions_task ON submissions(chapter_slug, task_slug);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_submissions_task;
+DROP TABLE IF EXISTS submission_rejudges;
+-- +goose StatementEnd
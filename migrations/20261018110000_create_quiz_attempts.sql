@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE quiz_attempts(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    question_ids TEXT[] NOT NULL,
+    score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    correct INTEGER NOT NULL DEFAULT 0,
+    started_at TIMESTAMP NOT NULL DEFAULT now(),
+    finished_at TIMESTAMP
+);
+
+CREATE TABLE quiz_answers(
+    attempt_id UUID NOT NULL REFERENCES quiz_attempts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    question_id VARCHAR(200) NOT NULL,
+    answer JSONB NOT NULL,
+    correct BOOLEAN NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    answered_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY(attempt_id, question_id)
+);
+
+CREATE INDEX idx_quiz_attempts_user ON quiz_attempts(user_id);
+CREATE INDEX idx_quiz_answers_user_question ON quiz_answers(user_id, question_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS quiz_answers;
+DROP TABLE IF EXISTS quiz_attempts;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE quiz_reviews(
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    question_id VARCHAR(200) NOT NULL,
+    revision VARCHAR(16) NOT NULL,
+    ease DOUBLE PRECISION NOT NULL,
+    interval_days INTEGER NOT NULL,
+    repetitions INTEGER NOT NULL,
+    due_at TIMESTAMP NOT NULL,
+    reviewed_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY(user_id, question_id)
+);
+
+CREATE INDEX idx_quiz_reviews_user_due ON quiz_reviews(user_id, due_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS quiz_reviews;
+-- +goose StatementEnd
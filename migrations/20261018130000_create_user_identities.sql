@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_identities(
+    provider VARCHAR(32) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (provider, subject),
+    UNIQUE (user_id, provider)
+);
+
+INSERT INTO user_identities (provider, subject, user_id, email, created_at)
+SELECT 'google', google_id, id, email, created_at FROM users;
+
+ALTER TABLE users DROP COLUMN google_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN google_id VARCHAR(255) UNIQUE;
+
+UPDATE users u
+SET google_id = i.subject
+FROM user_identities i
+WHERE i.user_id = u.id AND i.provider = 'google';
+
+-- аккаунты без Google не представимы в старой схеме: откат остановится
+-- на NOT NULL, пока их не удалят или не привяжут к Google вручную
+ALTER TABLE users ALTER COLUMN google_id SET NOT NULL;
+
+DROP TABLE user_identities;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'student'
+    CHECK (role IN ('student', 'mentor', 'admin'));
+
+CREATE INDEX idx_users_role ON users(role);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_users_role;
+ALTER TABLE users DROP COLUMN role;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN deletion_requested_at TIMESTAMP,
+    ADD COLUMN delete_after TIMESTAMP;
+
+CREATE INDEX idx_users_delete_after ON users(delete_after) WHERE delete_after IS NOT NULL;
+
+-- без внешнего ключа и персональных данных: запись переживает удаление пользователя
+CREATE TABLE account_deletions(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    requested_at TIMESTAMP,
+    scheduled_at TIMESTAMP NOT NULL,
+    purged_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_account_deletions_user_id ON account_deletions(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE account_deletions;
+DROP INDEX idx_users_delete_after;
+ALTER TABLE users
+    DROP COLUMN delete_after,
+    DROP COLUMN deletion_requested_at;
+-- +goose StatementEnd
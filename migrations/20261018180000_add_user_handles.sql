@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN handle VARCHAR(30) UNIQUE,
+    ADD COLUMN handle_changed_at TIMESTAMP,
+    ADD COLUMN profile_public BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN show_stats BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN show_chapters BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN show_projects BOOLEAN NOT NULL DEFAULT TRUE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+    DROP COLUMN show_projects,
+    DROP COLUMN show_chapters,
+    DROP COLUMN show_stats,
+    DROP COLUMN profile_public,
+    DROP COLUMN handle_changed_at,
+    DROP COLUMN handle;
+-- +goose StatementEnd